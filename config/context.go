@@ -77,6 +77,24 @@ func SetFilePrefix[C any](prefix string, types ...Type) Option[C] {
 	})
 }
 
+func AddEnv[C any](prefix string, separator string) Option[C] {
+	return optionFunc[C](func(ctx *Context[C]) {
+		ctx.AddEnv(prefix, separator)
+	})
+}
+
+func SetEnv[C any](prefix string, separator string) Option[C] {
+	return optionFunc[C](func(ctx *Context[C]) {
+		ctx.SetEnv(prefix, separator)
+	})
+}
+
+func SetFlags[C any](flags *FlagSet) Option[C] {
+	return optionFunc[C](func(ctx *Context[C]) {
+		ctx.SetFlags(flags)
+	})
+}
+
 type (
 	OnConfigReloaded[C any]    func(oc, nc *C)
 	ConfigReloadChecker[C any] func(oc, nc *C) error
@@ -0,0 +1,156 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// dotenvParser 为 .env 格式的解析器，每行为一个 KEY=VALUE 形式的变量，可以使用 export
+// 前缀。双引号中的值支持转义字符和换行，单引号中的值不做任何处理，双引号中和没有引号的值
+// 中的 ${VAR} 和 $VAR 使用之前定义的变量或环境变量替换
+type dotenvParser struct {
+	data string
+	pos  int
+	line int
+	vars map[string]string
+}
+
+func (p *dotenvParser) errorf(msg string) error {
+	return &SyntaxError{Format: "dotenv", Line: p.line, Msg: msg}
+}
+
+func (p *dotenvParser) expand(value string) string {
+	return os.Expand(value, func(key string) string {
+		if value, has := p.vars[key]; has {
+			return value
+		}
+		return os.Getenv(key)
+	})
+}
+
+// readLine 读取当前位置到行尾的内容
+func (p *dotenvParser) readLine() string {
+	end := strings.IndexByte(p.data[p.pos:], '\n')
+	if end < 0 {
+		end = len(p.data) - p.pos
+	}
+	line := p.data[p.pos : p.pos+end]
+	p.pos += end
+	return strings.TrimSuffix(line, "\r")
+}
+
+func (p *dotenvParser) parseQuoted(quote byte) (string, error) {
+	p.pos++
+	sb := strings.Builder{}
+	for p.pos < len(p.data) {
+		c := p.data[p.pos]
+		p.pos++
+		switch {
+		case c == quote:
+			if rest := strings.TrimSpace(p.readLine()); rest != "" && rest[0] != '#' {
+				return "", p.errorf("引号之后有多余的内容")
+			}
+			return sb.String(), nil
+		case c == '\n':
+			p.line++
+			sb.WriteByte(c)
+		case c == '\\' && quote == '"' && p.pos < len(p.data):
+			e := p.data[p.pos]
+			p.pos++
+			switch e {
+			case 'n':
+				sb.WriteByte('\n')
+			case 'r':
+				sb.WriteByte('\r')
+			case 't':
+				sb.WriteByte('\t')
+			case '"', '\\', '$':
+				sb.WriteByte(e)
+			default:
+				sb.WriteByte('\\')
+				sb.WriteByte(e)
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	return "", p.errorf("引号没有结束")
+}
+
+func (p *dotenvParser) parse() error {
+	for {
+		// 跳过空行和注释
+		for p.pos < len(p.data) && strings.IndexByte(" \t\r\n", p.data[p.pos]) >= 0 {
+			if p.data[p.pos] == '\n' {
+				p.line++
+			}
+			p.pos++
+		}
+		if p.pos >= len(p.data) {
+			return nil
+		}
+		if p.data[p.pos] == '#' {
+			p.readLine()
+			continue
+		}
+		end := strings.IndexByte(p.data[p.pos:], '\n')
+		if end < 0 {
+			end = len(p.data) - p.pos
+		}
+		sep := strings.IndexByte(p.data[p.pos:p.pos+end], '=')
+		if sep <= 0 {
+			return p.errorf("无效的变量定义")
+		}
+		key := strings.TrimSpace(strings.TrimPrefix(p.data[p.pos:p.pos+sep], "export "))
+		if key == "" {
+			return p.errorf("变量名称不能为空")
+		}
+		p.pos += sep + 1
+		for p.pos < len(p.data) && (p.data[p.pos] == ' ' || p.data[p.pos] == '\t') {
+			p.pos++
+		}
+		var value string
+		if p.pos < len(p.data) && (p.data[p.pos] == '"' || p.data[p.pos] == '\'') {
+			quote := p.data[p.pos]
+			v, err := p.parseQuoted(quote)
+			if err != nil {
+				return err
+			}
+			if value = v; quote == '"' {
+				value = p.expand(v)
+			}
+		} else {
+			raw := p.readLine()
+			for _, mark := range []string{" #", "\t#"} {
+				if i := strings.Index(raw, mark); i >= 0 {
+					raw = raw[:i]
+				}
+			}
+			value = p.expand(strings.TrimSpace(raw))
+		}
+		p.vars[key] = value
+	}
+}
+
+func parseDotenv(data []byte) (map[string]string, error) {
+	p := &dotenvParser{data: strings.TrimPrefix(string(data), "\uFEFF"), line: 1, vars: make(map[string]string)}
+	if err := p.parse(); err != nil {
+		return nil, err
+	}
+	return p.vars, nil
+}
+
+// UnmarshalDotenv 解析 .env 格式的配置，变量名称与配置字段的对应关系与 Parser.AddEnv 相同，
+// 前缀为空，分隔符为 DefaultEnvSeparator，例如 SERVER__PORT 对应 server.port
+func UnmarshalDotenv(data []byte, c interface{}) error {
+	vars, err := parseDotenv(data)
+	if err != nil {
+		return err
+	}
+	p := newEnvParser("", "")
+	p.lookup = func(key string) (string, bool) {
+		value, has := vars[key]
+		return value, has
+	}
+	return p.Unmarshal(c)
+}
@@ -0,0 +1,206 @@
+package config
+
+import (
+	"encoding"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"time"
+)
+
+const DefaultEnvSeparator = "__"
+
+// EnvError 为环境变量的值无法转换为配置字段的类型时返回的错误
+type EnvError struct {
+	Name  string
+	Value string
+	Err   error
+}
+
+func (e *EnvError) Error() string {
+	return fmt.Sprintf("解析环境变量(%s=%s)失败: %s", e.Name, e.Value, e.Err.Error())
+}
+
+func (e *EnvError) Unwrap() error {
+	return e.Err
+}
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// envParser 使用环境变量覆盖配置字段的值，环境变量的名称由前缀和字段的路径组成，字段的
+// 名称优先使用 yaml 标签中的名称，其次为 json 标签中的名称，都没有时使用字段名的小写形
+// 式。名称中的字母转换为大写，字母和数字以外的字符转换为下划线，路径中的各级字段名称使用
+// 分隔符连接，例如前缀为 APP，分隔符为 __ 时，server.port 对应的环境变量为
+// APP_SERVER__PORT
+type envParser struct {
+	prefix    string
+	separator string
+	lookup    func(key string) (string, bool)
+}
+
+func newEnvParser(prefix, separator string) *envParser {
+	if separator == "" {
+		separator = DefaultEnvSeparator
+	}
+	return &envParser{
+		prefix:    strings.TrimSuffix(envName(prefix), "_"),
+		separator: separator,
+		lookup:    os.LookupEnv,
+	}
+}
+
+func envName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, name)
+}
+
+// fieldName 返回字段在配置文件中的名称，inline 表示字段的子字段直接作为当前结构体的字段
+func fieldName(field reflect.StructField) (name string, inline bool, skip bool) {
+	if tag, has := field.Tag.Lookup("yaml"); has {
+		name, opts, _ := strings.Cut(tag, ",")
+		if name == "-" && opts == "" {
+			return "", false, true
+		}
+		for _, opt := range strings.Split(opts, ",") {
+			if opt == "inline" {
+				return "", true, false
+			}
+		}
+		if name != "" {
+			return name, false, false
+		}
+	}
+	if tag, has := field.Tag.Lookup("json"); has {
+		if name, _, _ := strings.Cut(tag, ","); name == "-" {
+			return "", false, true
+		} else if name != "" {
+			return name, false, false
+		}
+	}
+	return strings.ToLower(field.Name), false, false
+}
+
+// isLeaf 判断类型是否作为一个整体从环境变量中解析，结构体(time.Time 和实现了
+// encoding.TextUnmarshaler 的结构体除外)及其指针会继续解析每个字段
+func isLeaf(t reflect.Type) bool {
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct || t == timeType {
+		return true
+	}
+	return reflect.PtrTo(t).Implements(textUnmarshalerType)
+}
+
+// parseValue 将字符串转换为字段类型的值，转换的规则与 default 标签相同
+func parseValue(typ reflect.Type, field *reflect.StructField, value string) (reflect.Value, error) {
+	timeLayer := time.RFC3339Nano
+	if field != nil {
+		if layer, has := field.Tag.Lookup("timeLayer"); has {
+			timeLayer = layer
+		}
+	}
+	// 值为零值时 handleDefault 才会使用默认值设置，所以使用新的零值转换
+	nv := reflect.New(typ).Elem()
+	if err := handleDefault(nv, nil, &timeLayer, &value, make(map[any]struct{})); err != nil {
+		return reflect.Value{}, err
+	}
+	return nv, nil
+}
+
+// overlay 使用环境变量覆盖结构体的字段，返回是否有字段被设置。visiting 为当前路径上的
+// 结构体类型，用于避免递归定义的结构体无限展开
+func (p *envParser) overlay(v reflect.Value, name string, visiting map[reflect.Type]struct{}) (set bool, err error) {
+	vt := v.Type()
+	if _, has := visiting[vt]; has {
+		return false, nil
+	}
+	visiting[vt] = struct{}{}
+	defer delete(visiting, vt)
+	for i := 0; i < v.NumField(); i++ {
+		field := vt.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fname, inline, skip := fieldName(field)
+		if skip {
+			continue
+		}
+		fv := v.Field(i)
+		if inline {
+			fname = name
+		} else {
+			if name != "" {
+				fname = name + p.separator + envName(fname)
+			} else {
+				fname = envName(fname)
+			}
+			key := p.key(fname)
+			if value, has := p.lookup(key); has {
+				nv, err := parseValue(fv.Type(), &field, value)
+				if err != nil {
+					return set, &EnvError{Name: key, Value: value, Err: err}
+				}
+				fv.Set(nv)
+				set = true
+				continue
+			}
+		}
+		if isLeaf(field.Type) {
+			continue
+		}
+		fset, err := p.overlayValue(fv, fname, visiting)
+		if err != nil {
+			return set, err
+		}
+		set = set || fset
+	}
+	return set, nil
+}
+
+// overlayValue 使用环境变量覆盖结构体或结构体指针，指针为空时只有存在对应的环境变量才会
+// 创建新的结构体
+func (p *envParser) overlayValue(v reflect.Value, name string, visiting map[reflect.Type]struct{}) (bool, error) {
+	if v.Kind() != reflect.Ptr {
+		return p.overlay(v, name, visiting)
+	}
+	if !v.IsNil() {
+		return p.overlay(v.Elem(), name, visiting)
+	}
+	nv := reflect.New(v.Type().Elem())
+	set, err := p.overlay(nv.Elem(), name, visiting)
+	if err != nil || !set {
+		return false, err
+	}
+	v.Set(nv)
+	return true, nil
+}
+
+// key 返回字段路径对应的环境变量名称，前缀与字段路径之间使用一个下划线连接
+func (p *envParser) key(path string) string {
+	if p.prefix == "" {
+		return path
+	}
+	return p.prefix + "_" + path
+}
+
+func (p *envParser) Unmarshal(c interface{}) error {
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	_, err := p.overlay(v.Elem(), "", make(map[reflect.Type]struct{}))
+	return err
+}
@@ -0,0 +1,94 @@
+package config
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/unit"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type envConfig struct {
+	Log struct {
+		Level string `yaml:"level" default:"info"`
+	} `yaml:"log"`
+	Server *struct {
+		Port    int           `yaml:"port" default:"8080"`
+		Timeout time.Duration `yaml:"timeout"`
+	} `yaml:"server"`
+	MaxSize unit.Size `yaml:"max-size"`
+	IP      net.IP    `json:"ip"`
+	Hosts   []string  `yaml:"hosts"`
+	Start   time.Time `yaml:"start" timeLayer:"2006-01-02"`
+	Enable  bool
+	Ignored string `yaml:"-"`
+	Next    *envConfig
+}
+
+func TestEnvParser(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	if err := os.WriteFile(path, []byte("log: {level: debug}\nserver: {port: 9000}\nhosts: [a]\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_LOG__LEVEL", "warn")
+	t.Setenv("APP_SERVER__TIMEOUT", "3s")
+	t.Setenv("APP_MAX_SIZE", "4MiB")
+	t.Setenv("APP_IP", "192.168.1.1")
+	t.Setenv("APP_HOSTS", "[b, c]")
+	t.Setenv("APP_START", "2024-01-02")
+	t.Setenv("APP_ENABLE", "true")
+	t.Setenv("APP_IGNORED", "value")
+
+	p := Parser{}
+	p.AddFile(path, nil)
+	p.AddEnv("APP", "")
+	c := new(envConfig)
+	if err := p.Unmarshal(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Log.Level != "warn" {
+		t.Errorf("env should override file, got log level %s", c.Log.Level)
+	}
+	if c.Server == nil || c.Server.Port != 9000 || c.Server.Timeout != time.Second*3 {
+		t.Errorf("unexpected server config %+v", c.Server)
+	}
+	if c.MaxSize != 4*unit.MeBiByte || c.IP.String() != "192.168.1.1" || len(c.Hosts) != 2 || c.Hosts[1] != "c" {
+		t.Errorf("unexpected config %+v", c)
+	}
+	if c.Start.Year() != 2024 || !c.Enable || c.Ignored != "" || c.Next != nil {
+		t.Errorf("unexpected config %+v", c)
+	}
+
+	// 环境变量总是在配置文件之后应用，与添加的顺序无关
+	p = Parser{}
+	p.AddEnv("APP", "")
+	p.AddFile(path, nil)
+	c = new(envConfig)
+	if err := p.Unmarshal(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Log.Level != "warn" {
+		t.Errorf("env added before file should still override file, got log level %s", c.Log.Level)
+	}
+
+	// SetEnv 只替换环境变量配置源，保留已经添加的配置文件
+	t.Setenv("OTHER_LOG__LEVEL", "error")
+	p.SetEnv("OTHER", "")
+	c = new(envConfig)
+	if err := p.Unmarshal(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Log.Level != "error" || c.Server == nil || c.Server.Port != 9000 || c.MaxSize != 0 {
+		t.Errorf("set env should keep files and replace env, got %+v", c)
+	}
+	p.SetEnv("APP", "")
+
+	t.Setenv("APP_SERVER__PORT", "port")
+	var envErr *EnvError
+	if err := p.Unmarshal(new(envConfig)); !errors.As(err, &envErr) || envErr.Name != "APP_SERVER__PORT" {
+		t.Errorf("expect env error, got %v", err)
+	}
+}
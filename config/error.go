@@ -1,5 +1,7 @@
 package config
 
+import "fmt"
+
 type ErrorType int
 
 const (
@@ -43,3 +45,14 @@ type Error struct {
 func (e Error) Error() string {
 	return e.Type.Type() + ": " + e.Err.Error()
 }
+
+// SyntaxError 为配置内容不符合配置格式时返回的错误，Line 为出错的行号
+type SyntaxError struct {
+	Format string
+	Line   int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("%s格式错误(第%d行): %s", e.Format, e.Line, e.Msg)
+}
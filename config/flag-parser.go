@@ -0,0 +1,155 @@
+package config
+
+import (
+	"flag"
+	"reflect"
+	"time"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// flagValue 为配置字段对应的命令行参数，实现了 flag.Value 和 pflag.Value 接口。参数的值
+// 在设置时检查是否可以转换为字段的类型，在解析配置时才设置到配置字段中
+type flagValue struct {
+	index []int
+	field reflect.StructField
+	value string
+}
+
+func (v *flagValue) String() string {
+	return v.value
+}
+
+func (v *flagValue) Set(s string) error {
+	if _, err := parseValue(v.field.Type, &v.field, s); err != nil {
+		return err
+	}
+	v.value = s
+	return nil
+}
+
+// Type 返回参数值的类型名称，用于 pflag 显示参数的用法
+func (v *flagValue) Type() string {
+	typ := v.field.Type
+	if typ == nil {
+		return ""
+	}
+	if typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	switch {
+	case typ == durationType:
+		return "duration"
+	case typ == timeType:
+		return "time"
+	}
+	switch typ.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return "int"
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "uint"
+	case reflect.Float32, reflect.Float64:
+		return "float"
+	case reflect.Bool:
+		return "bool"
+	case reflect.String:
+		return "string"
+	default:
+		return "value"
+	}
+}
+
+// IsBoolFlag 使布尔类型的参数可以省略参数值
+func (v *flagValue) IsBoolFlag() bool {
+	typ := v.field.Type
+	if typ != nil && typ.Kind() == reflect.Ptr {
+		typ = typ.Elem()
+	}
+	return typ != nil && typ.Kind() == reflect.Bool
+}
+
+// apply 将参数的值设置到配置字段中，路径上的空指针会创建新的结构体
+func (v *flagValue) apply(c reflect.Value) error {
+	for _, i := range v.index {
+		if c.Kind() == reflect.Ptr {
+			if c.IsNil() {
+				c.Set(reflect.New(c.Type().Elem()))
+			}
+			c = c.Elem()
+		}
+		c = c.Field(i)
+	}
+	nv, err := parseValue(v.field.Type, &v.field, v.value)
+	if err != nil {
+		return err
+	}
+	c.Set(nv)
+	return nil
+}
+
+// FlagSet 为根据配置结构体生成的命令行参数集合，参数的名称为字段在配置文件中的路径，各级
+// 字段的名称使用点号连接，例如 server.port。参数的用法来自字段的 usage 标签，默认值来自
+// 字段的 default 标签。需要使用 pflag 时可以通过 pflag.FlagSet.AddGoFlagSet 添加
+type FlagSet struct {
+	*flag.FlagSet
+}
+
+// NewFlagSet 根据配置类型 C 生成命令行参数集合，C 必须为结构体类型
+func NewFlagSet[C any](name string, errorHandling flag.ErrorHandling) *FlagSet {
+	s := &FlagSet{FlagSet: flag.NewFlagSet(name, errorHandling)}
+	typ := reflect.TypeOf((*C)(nil)).Elem()
+	if typ.Kind() == reflect.Struct {
+		s.define(typ, "", nil, make(map[reflect.Type]struct{}))
+	}
+	return s
+}
+
+func (s *FlagSet) define(typ reflect.Type, name string, index []int, visiting map[reflect.Type]struct{}) {
+	if _, has := visiting[typ]; has {
+		return
+	}
+	visiting[typ] = struct{}{}
+	defer delete(visiting, typ)
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		fname, inline, skip := fieldName(field)
+		if skip {
+			continue
+		}
+		if inline {
+			fname = name
+		} else if name != "" {
+			fname = name + "." + fname
+		}
+		findex := append(index[:len(index):len(index)], i)
+		if isLeaf(field.Type) {
+			if inline || s.Lookup(fname) != nil {
+				continue
+			}
+			s.Var(&flagValue{index: findex, field: field, value: field.Tag.Get("default")}, fname, field.Tag.Get("usage"))
+			continue
+		}
+		ftype := field.Type
+		if ftype.Kind() == reflect.Ptr {
+			ftype = ftype.Elem()
+		}
+		s.define(ftype, fname, findex, visiting)
+	}
+}
+
+// apply 将命令行中设置的参数应用到配置中，没有在命令行中设置的参数不会修改配置
+func (s *FlagSet) apply(c any) (err error) {
+	v := reflect.ValueOf(c)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return nil
+	}
+	s.Visit(func(f *flag.Flag) {
+		if value, is := f.Value.(*flagValue); is && err == nil {
+			err = value.apply(v.Elem())
+		}
+	})
+	return
+}
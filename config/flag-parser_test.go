@@ -0,0 +1,70 @@
+package config
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type flagConfig struct {
+	Log struct {
+		Level string `yaml:"level" default:"info" usage:"日志级别"`
+	} `yaml:"log"`
+	Server *struct {
+		Port    int           `yaml:"port" default:"8080" usage:"监听端口"`
+		Timeout time.Duration `yaml:"timeout" default:"5s"`
+	} `yaml:"server"`
+	Debug bool     `yaml:"debug"`
+	Hosts []string `json:"hosts"`
+	Next  *flagConfig
+}
+
+func TestFlagSet(t *testing.T) {
+	flags := NewFlagSet[flagConfig]("test", flag.ContinueOnError)
+	if f := flags.Lookup("server.port"); f == nil || f.DefValue != "8080" || f.Usage != "监听端口" {
+		t.Fatalf("unexpected flag %+v", f)
+	}
+	for _, name := range []string{"log.level", "server.timeout", "debug", "hosts"} {
+		if flags.Lookup(name) == nil {
+			t.Errorf("flag %s not defined", name)
+		}
+	}
+	if flags.Lookup("next.debug") != nil {
+		t.Error("recursive struct should not be expanded")
+	}
+	output := bytes.Buffer{}
+	flags.SetOutput(&output)
+	flags.PrintDefaults()
+	if !strings.Contains(output.String(), "日志级别 (default info)") {
+		t.Errorf("unexpected usage:\n%s", output.String())
+	}
+	if err := flags.Parse([]string{"-server.port", "port"}); err == nil {
+		t.Error("invalid flag value should be rejected")
+	}
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "app.yaml")
+	if err := os.WriteFile(path, []byte("log: {level: debug}\nserver: {port: 9000, timeout: 1s}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("APP_SERVER__PORT", "9001")
+	flags = NewFlagSet[flagConfig]("test", flag.ContinueOnError)
+	if err := flags.Parse([]string{"-server.port=9002", "-debug", "-hosts", "[a, b]"}); err != nil {
+		t.Fatal(err)
+	}
+	p := Parser{}
+	p.SetFlags(flags)
+	p.AddFile(path, nil)
+	p.AddEnv("APP", "")
+	c := new(flagConfig)
+	if err := p.Unmarshal(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Log.Level != "debug" || c.Server.Port != 9002 || c.Server.Timeout != time.Second || !c.Debug || len(c.Hosts) != 2 {
+		t.Errorf("unexpected config %+v %+v", c, c.Server)
+	}
+}
@@ -588,8 +588,12 @@ func PreHandle(c any) error {
 	return handle(reflect.ValueOf(c), nil, preHandler{}, make(map[interface{}]struct{}))
 }
 
+// PostHandle 调用配置的后置处理函数，然后根据 validate 标签校验配置
 func PostHandle(c any) error {
-	return handle(reflect.ValueOf(c), nil, postHandler{}, make(map[interface{}]struct{}))
+	if err := handle(reflect.ValueOf(c), nil, postHandler{}, make(map[interface{}]struct{})); err != nil {
+		return err
+	}
+	return Validate(c)
 }
 
 //func handle3(handler handler, c any, cs map[any]struct{}) (nc any, modified bool, err error) {
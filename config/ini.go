@@ -0,0 +1,103 @@
+package config
+
+import (
+	"gopkg.in/yaml.v3"
+	"strconv"
+	"strings"
+)
+
+// iniValue 解析 INI 格式中的值，引号中的值作为字符串，没有引号的值去掉行内注释后由 yaml
+// 根据配置字段的类型转换
+func iniValue(value string) (node *yaml.Node, err error) {
+	value = strings.TrimSpace(value)
+	if n := len(value); n >= 2 && (value[0] == '"' && value[n-1] == '"' || value[0] == '\'' && value[n-1] == '\'') {
+		if value[0] == '"' {
+			if value, err = strconv.Unquote(value); err != nil {
+				return nil, err
+			}
+		} else {
+			value = value[1 : n-1]
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Style: yaml.DoubleQuotedStyle, Tag: "!!str", Value: value}, nil
+	}
+	for _, mark := range []string{" ;", " #", "\t;", "\t#"} {
+		if i := strings.Index(value, mark); i >= 0 {
+			value = strings.TrimSpace(value[:i])
+		}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Value: value}, nil
+}
+
+// mappingValue 返回 yaml 映射节点中 key 对应的值，不存在时返回 nil
+func mappingValue(mapping *yaml.Node, key string) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
+
+func setMappingValue(mapping *yaml.Node, key string, value *yaml.Node) {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			mapping.Content[i+1] = value
+			return
+		}
+	}
+	mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key}, value)
+}
+
+// parseIni 将 INI 格式的配置解析为 yaml 节点，节(section)的名称中的点号表示嵌套的节，
+// 键和值之间可以使用等号或冒号分隔，以分号或井号开头的行为注释
+func parseIni(data []byte) (*yaml.Node, error) {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+	current := root
+	for i, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if i == 0 {
+			line = strings.TrimPrefix(line, "\uFEFF")
+		}
+		if line == "" || line[0] == ';' || line[0] == '#' {
+			continue
+		}
+		if line[0] == '[' {
+			end := strings.IndexByte(line, ']')
+			if end < 0 {
+				return nil, &SyntaxError{Format: "INI", Line: i + 1, Msg: "节缺少结束标记"}
+			}
+			current = root
+			for _, name := range strings.Split(line[1:end], ".") {
+				name = strings.TrimSpace(name)
+				section := mappingValue(current, name)
+				if section == nil {
+					section = &yaml.Node{Kind: yaml.MappingNode}
+					setMappingValue(current, name, section)
+				} else if section.Kind != yaml.MappingNode {
+					return nil, &SyntaxError{Format: "INI", Line: i + 1, Msg: "节(" + name + ")与键重名"}
+				}
+				current = section
+			}
+			continue
+		}
+		sep := strings.IndexAny(line, "=:")
+		if sep <= 0 {
+			return nil, &SyntaxError{Format: "INI", Line: i + 1, Msg: "无效的键值对"}
+		}
+		value, err := iniValue(line[sep+1:])
+		if err != nil {
+			return nil, &SyntaxError{Format: "INI", Line: i + 1, Msg: err.Error()}
+		}
+		setMappingValue(current, strings.TrimSpace(line[:sep]), value)
+	}
+	return root, nil
+}
+
+// UnmarshalIni 解析 INI 格式的配置，配置字段的名称与 yaml 格式相同
+func UnmarshalIni(data []byte, c interface{}) error {
+	node, err := parseIni(data)
+	if err != nil {
+		return err
+	}
+	return node.Decode(c)
+}
@@ -10,6 +10,8 @@ type parser interface {
 
 type Parser struct {
 	parsers []parser
+	envs    []*envParser
+	flags   *FlagSet
 }
 
 func (p *Parser) AddBytes(bs []byte, typ Type) {
@@ -42,7 +44,13 @@ func (p *Parser) SetFile(path string, typ *Type) {
 	p.AddFile(path, typ)
 }
 
+// AddFilePrefix 添加指定前缀的配置文件，依次查找前缀加上各个类型后缀的文件，使用第一个
+// 存在的文件。没有指定类型时只查找 yaml 和 json 格式的文件，需要查找其他格式的文件时需要
+// 指定类型，例如 Types() 返回的所有已注册的类型
 func (p *Parser) AddFilePrefix(prefix string, types ...Type) {
+	if len(types) == 0 {
+		types = []Type{TypeYaml, TypeJson}
+	}
 	group := &parserGroup{errorIgnore: os.IsNotExist}
 	for _, typ := range types {
 		for _, suffix := range typ.Suffixes {
@@ -60,6 +68,47 @@ func (p *Parser) SetFilePrefix(prefix string, types ...Type) {
 	p.AddFilePrefix(prefix, types...)
 }
 
+// Files 返回所有通过 AddFile 和 AddFilePrefix 添加的配置文件路径，包括当前不存在的文件
+func (p *Parser) Files() []string {
+	var files []string
+	for _, parser := range p.parsers {
+		files = appendFiles(files, parser)
+	}
+	return files
+}
+
+func appendFiles(files []string, p parser) []string {
+	switch p := p.(type) {
+	case *fileParser:
+		return append(files, p.path)
+	case *parserGroup:
+		for _, parser := range p.parsers {
+			files = appendFiles(files, parser)
+		}
+	}
+	return files
+}
+
+// AddEnv 添加环境变量配置源，环境变量的名称为 prefix 与字段路径使用下划线连接，字段路径
+// 中各级字段的名称使用 separator 连接，separator 为空时使用 DefaultEnvSeparator。例如
+// prefix 为 APP 时，log.level 对应的环境变量为 APP_LOG__LEVEL。环境变量配置源与添加的
+// 顺序无关，总是在所有配置文件解析完成后按照添加的顺序应用，覆盖配置文件中的值
+func (p *Parser) AddEnv(prefix string, separator string) {
+	p.envs = append(p.envs, newEnvParser(prefix, separator))
+}
+
+// SetEnv 替换所有通过 AddEnv 添加的环境变量配置源，不影响已经添加的配置文件
+func (p *Parser) SetEnv(prefix string, separator string) {
+	p.envs = p.envs[:0]
+	p.AddEnv(prefix, separator)
+}
+
+// SetFlags 设置命令行参数配置源，命令行中设置的参数在所有配置源解析完成后应用，优先级
+// 最高。flags 需要在解析配置前调用 Parse 解析命令行参数
+func (p *Parser) SetFlags(flags *FlagSet) {
+	p.flags = flags
+}
+
 func (p *Parser) Unmarshal(c interface{}) error {
 	if err := HandleDefault(c); err != nil {
 		return err
@@ -73,6 +122,16 @@ func (p *Parser) Unmarshal(c interface{}) error {
 			return err
 		}
 	}
+	for _, env := range p.envs {
+		if err := env.Unmarshal(c); err != nil {
+			return err
+		}
+	}
+	if p.flags != nil {
+		if err := p.flags.apply(c); err != nil {
+			return err
+		}
+	}
 	if err := PostHandle(c); err != nil {
 		return err
 	}
@@ -0,0 +1,577 @@
+package config
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v3"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// tomlParser 为 TOML 格式的解析器，支持 TOML v1.0 的表、表数组、点分隔的键、内联表、
+// 数组、多行字符串以及日期时间。解析的结果为 map[string]any，之后通过 yaml 解析到配置中，
+// 所以配置字段的名称与 yaml 格式相同
+type tomlParser struct {
+	data string
+	pos  int
+	line int
+
+	root    map[string]any
+	current map[string]any
+	// path 为当前表的路径
+	path []string
+	// defined 记录通过 [table] 或者点分隔的键定义过的表，同一个表不能定义两次
+	defined map[string]bool
+	// arrays 记录通过 [[array]] 定义的表数组，与通过值定义的数组区分
+	arrays map[string]bool
+}
+
+// tomlName 返回表的路径对应的名称，作为 defined 和 arrays 的键
+func tomlName(keys []string) string {
+	return strings.Join(keys, "\x00")
+}
+
+// forget 在表数组添加新的表时清除之前的表中定义过的子表，新的表中可以重新定义这些子表
+func (p *tomlParser) forget(name string) {
+	prefix := name + "\x00"
+	for key := range p.defined {
+		if strings.HasPrefix(key, prefix) {
+			delete(p.defined, key)
+		}
+	}
+	for key := range p.arrays {
+		if strings.HasPrefix(key, prefix) {
+			delete(p.arrays, key)
+		}
+	}
+}
+
+func (p *tomlParser) errorf(format string, args ...any) error {
+	return &SyntaxError{Format: "TOML", Line: p.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (p *tomlParser) eof() bool {
+	return p.pos >= len(p.data)
+}
+
+func (p *tomlParser) peek() byte {
+	if p.eof() {
+		return 0
+	}
+	return p.data[p.pos]
+}
+
+func (p *tomlParser) next() byte {
+	c := p.data[p.pos]
+	p.pos++
+	if c == '\n' {
+		p.line++
+	}
+	return c
+}
+
+func (p *tomlParser) skipSpace() {
+	for !p.eof() && (p.peek() == ' ' || p.peek() == '\t') {
+		p.pos++
+	}
+}
+
+func (p *tomlParser) skipComment() {
+	if p.peek() == '#' {
+		for !p.eof() && p.peek() != '\n' {
+			p.pos++
+		}
+	}
+}
+
+// skipBlank 跳过空白字符、换行和注释
+func (p *tomlParser) skipBlank() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.next()
+		case '#':
+			p.skipComment()
+		default:
+			return
+		}
+	}
+}
+
+// endLine 检查一行中剩余的内容只有空白字符和注释
+func (p *tomlParser) endLine() error {
+	p.skipSpace()
+	p.skipComment()
+	if p.eof() {
+		return nil
+	}
+	if p.peek() == '\r' {
+		p.pos++
+	}
+	if p.eof() || p.next() != '\n' {
+		return p.errorf("一行中只能有一个键值对")
+	}
+	return nil
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func (p *tomlParser) parseKey() ([]string, error) {
+	var keys []string
+	for {
+		p.skipSpace()
+		switch c := p.peek(); {
+		case c == '"':
+			key, err := p.parseBasicString()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		case c == '\'':
+			key, err := p.parseLiteralString()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+		case isBareKeyChar(c):
+			start := p.pos
+			for !p.eof() && isBareKeyChar(p.peek()) {
+				p.pos++
+			}
+			keys = append(keys, p.data[start:p.pos])
+		default:
+			return nil, p.errorf("无效的键")
+		}
+		p.skipSpace()
+		if p.peek() != '.' {
+			return keys, nil
+		}
+		p.pos++
+	}
+}
+
+func (p *tomlParser) parseEscape(sb *strings.Builder) error {
+	if p.eof() {
+		return p.errorf("字符串没有结束")
+	}
+	switch c := p.next(); c {
+	case 'b':
+		sb.WriteByte('\b')
+	case 't':
+		sb.WriteByte('\t')
+	case 'n':
+		sb.WriteByte('\n')
+	case 'f':
+		sb.WriteByte('\f')
+	case 'r':
+		sb.WriteByte('\r')
+	case '"', '\\':
+		sb.WriteByte(c)
+	case 'u', 'U':
+		n := 4
+		if c == 'U' {
+			n = 8
+		}
+		if p.pos+n > len(p.data) {
+			return p.errorf("无效的转义字符")
+		}
+		r, err := strconv.ParseUint(p.data[p.pos:p.pos+n], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(r)) {
+			return p.errorf("无效的转义字符")
+		}
+		p.pos += n
+		sb.WriteRune(rune(r))
+	default:
+		return p.errorf("无效的转义字符(\\%c)", c)
+	}
+	return nil
+}
+
+func (p *tomlParser) parseBasicString() (string, error) {
+	p.pos++
+	sb := strings.Builder{}
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("字符串没有结束")
+		}
+		switch c := p.next(); c {
+		case '"':
+			return sb.String(), nil
+		case '\\':
+			if err := p.parseEscape(&sb); err != nil {
+				return "", err
+			}
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+func (p *tomlParser) parseLiteralString() (string, error) {
+	p.pos++
+	start := p.pos
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", p.errorf("字符串没有结束")
+		}
+		if p.next() == '\'' {
+			return p.data[start : p.pos-1], nil
+		}
+	}
+}
+
+// trimFirstNewline 去掉多行字符串开头紧跟的换行
+func (p *tomlParser) trimFirstNewline() {
+	if strings.HasPrefix(p.data[p.pos:], "\r\n") {
+		p.pos++
+	}
+	if p.peek() == '\n' {
+		p.next()
+	}
+}
+
+func (p *tomlParser) parseMultilineBasicString() (string, error) {
+	p.pos += 3
+	p.trimFirstNewline()
+	sb := strings.Builder{}
+	for {
+		if p.eof() {
+			return "", p.errorf("多行字符串没有结束")
+		}
+		if strings.HasPrefix(p.data[p.pos:], `"""`) {
+			p.pos += 3
+			// 结束标记前最多可以有两个引号
+			for i := 0; i < 2 && p.peek() == '"'; i++ {
+				sb.WriteByte(p.next())
+			}
+			return sb.String(), nil
+		}
+		c := p.next()
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+		// 行尾的反斜杠去掉换行以及之后的空白字符
+		if rest := strings.TrimLeft(p.data[p.pos:], " \t"); strings.HasPrefix(rest, "\n") || strings.HasPrefix(rest, "\r\n") {
+			p.skipBlankLines()
+			continue
+		}
+		if err := p.parseEscape(&sb); err != nil {
+			return "", err
+		}
+	}
+}
+
+func (p *tomlParser) skipBlankLines() {
+	for !p.eof() {
+		switch p.peek() {
+		case ' ', '\t', '\r', '\n':
+			p.next()
+		default:
+			return
+		}
+	}
+}
+
+func (p *tomlParser) parseMultilineLiteralString() (string, error) {
+	p.pos += 3
+	p.trimFirstNewline()
+	start := p.pos
+	for {
+		if p.eof() {
+			return "", p.errorf("多行字符串没有结束")
+		}
+		if strings.HasPrefix(p.data[p.pos:], "'''") {
+			end := p.pos
+			p.pos += 3
+			for i := 0; i < 2 && p.peek() == '\''; i++ {
+				p.pos++
+				end++
+			}
+			return p.data[start:end], nil
+		}
+		p.next()
+	}
+}
+
+func (p *tomlParser) parseArray() ([]any, error) {
+	p.pos++
+	array := make([]any, 0)
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("数组没有结束")
+		}
+		if p.peek() == ']' {
+			p.pos++
+			return array, nil
+		}
+		value, err := p.parseValue()
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case ']':
+		default:
+			return nil, p.errorf("数组元素之间缺少逗号")
+		}
+	}
+}
+
+func (p *tomlParser) parseInlineTable() (map[string]any, error) {
+	p.pos++
+	table := make(map[string]any)
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return nil, p.errorf("内联表没有结束")
+		}
+		if p.peek() == '}' {
+			p.pos++
+			return table, nil
+		}
+		if _, err := p.parseKeyValue(table); err != nil {
+			return nil, err
+		}
+		p.skipBlank()
+		switch p.peek() {
+		case ',':
+			p.pos++
+		case '}':
+		default:
+			return nil, p.errorf("内联表的键值对之间缺少逗号")
+		}
+	}
+}
+
+var tomlTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999",
+	"2006-01-02",
+}
+
+func (p *tomlParser) parseScalar() (any, error) {
+	start := p.pos
+	for !p.eof() {
+		c := p.peek()
+		if c == ',' || c == ']' || c == '}' || c == '#' || c == '\r' || c == '\n' || c == '\t' {
+			break
+		}
+		// 日期和时间之间可以使用空格分隔
+		if c == ' ' && !(p.pos-start == 10 && p.pos+1 < len(p.data) && p.data[p.pos+1] >= '0' && p.data[p.pos+1] <= '9' && p.data[start+4] == '-') {
+			break
+		}
+		p.pos++
+	}
+	token := p.data[start:p.pos]
+	switch token {
+	case "":
+		return nil, p.errorf("缺少值")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "inf", "+inf", "-inf", "nan", "+nan", "-nan":
+		f, _ := strconv.ParseFloat(strings.TrimPrefix(token, "+"), 64)
+		return f, nil
+	}
+	if i, err := strconv.ParseInt(token, 0, 64); err == nil {
+		if len(token) > 1 && token[0] == '0' && token[1] >= '0' && token[1] <= '9' {
+			return nil, p.errorf("整数不能有前导零(%s)", token)
+		}
+		return i, nil
+	}
+	if strings.ContainsAny(token, ".eE") && !strings.ContainsAny(token, ":") && strings.Count(token, "-") <= 2 {
+		if f, err := strconv.ParseFloat(strings.ReplaceAll(token, "_", ""), 64); err == nil {
+			return f, nil
+		}
+	}
+	// 日期时间中的 T 和 Z 可以使用小写
+	upper := strings.ToUpper(token)
+	for _, layout := range tomlTimeLayouts {
+		if t, err := time.Parse(layout, upper); err == nil {
+			return t, nil
+		}
+	}
+	if _, err := time.Parse("15:04:05.999999999", token); err == nil {
+		// 本地时间没有对应的类型，使用字符串表示
+		return token, nil
+	}
+	return nil, p.errorf("无效的值(%s)", token)
+}
+
+func (p *tomlParser) parseValue() (any, error) {
+	switch {
+	case strings.HasPrefix(p.data[p.pos:], `"""`):
+		return p.parseMultilineBasicString()
+	case strings.HasPrefix(p.data[p.pos:], "'''"):
+		return p.parseMultilineLiteralString()
+	}
+	switch p.peek() {
+	case '"':
+		return p.parseBasicString()
+	case '\'':
+		return p.parseLiteralString()
+	case '[':
+		return p.parseArray()
+	case '{':
+		return p.parseInlineTable()
+	}
+	return p.parseScalar()
+}
+
+// subTable 返回 table 中 key 对应的子表，不存在时创建。如果 key 对应的是表数组，返回最后
+// 一个表
+func (p *tomlParser) subTable(table map[string]any, key string) (map[string]any, error) {
+	switch sub := table[key].(type) {
+	case nil:
+		t := make(map[string]any)
+		table[key] = t
+		return t, nil
+	case map[string]any:
+		return sub, nil
+	case []any:
+		if len(sub) > 0 {
+			if t, is := sub[len(sub)-1].(map[string]any); is {
+				return t, nil
+			}
+		}
+	}
+	return nil, p.errorf("键(%s)已经被定义为非表类型", key)
+}
+
+func (p *tomlParser) parseKeyValue(table map[string]any) (keys []string, err error) {
+	if keys, err = p.parseKey(); err != nil {
+		return nil, err
+	}
+	if p.peek() != '=' {
+		return nil, p.errorf("键之后缺少等号")
+	}
+	p.pos++
+	p.skipSpace()
+	value, err := p.parseValue()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys[:len(keys)-1] {
+		if table, err = p.subTable(table, key); err != nil {
+			return nil, err
+		}
+	}
+	key := keys[len(keys)-1]
+	if _, has := table[key]; has {
+		return nil, p.errorf("键(%s)重复定义", strings.Join(keys, "."))
+	}
+	table[key] = value
+	return keys, nil
+}
+
+func (p *tomlParser) parseTableHeader() error {
+	array := strings.HasPrefix(p.data[p.pos:], "[[")
+	if array {
+		p.pos += 2
+	} else {
+		p.pos++
+	}
+	keys, err := p.parseKey()
+	if err != nil {
+		return err
+	}
+	if array {
+		if !strings.HasPrefix(p.data[p.pos:], "]]") {
+			return p.errorf("表数组缺少结束标记")
+		}
+		p.pos += 2
+	} else {
+		if p.peek() != ']' {
+			return p.errorf("表缺少结束标记")
+		}
+		p.pos++
+	}
+	table := p.root
+	for _, key := range keys[:len(keys)-1] {
+		if table, err = p.subTable(table, key); err != nil {
+			return err
+		}
+	}
+	key := keys[len(keys)-1]
+	name := tomlName(keys)
+	p.path = keys
+	if array {
+		tables, is := table[key].([]any)
+		if table[key] != nil && !(is && p.arrays[name]) {
+			return p.errorf("键(%s)已经被定义为非表数组类型", strings.Join(keys, "."))
+		}
+		p.arrays[name] = true
+		p.forget(name)
+		p.current = make(map[string]any)
+		table[key] = append(tables, p.current)
+		return nil
+	}
+	if _, is := table[key].([]any); is {
+		return p.errorf("键(%s)已经被定义为数组类型", strings.Join(keys, "."))
+	}
+	if p.defined[name] {
+		return p.errorf("表(%s)重复定义", strings.Join(keys, "."))
+	}
+	p.defined[name] = true
+	p.current, err = p.subTable(table, key)
+	return err
+}
+
+func parseToml(data []byte) (map[string]any, error) {
+	p := &tomlParser{
+		data:    string(data),
+		line:    1,
+		root:    make(map[string]any),
+		defined: make(map[string]bool),
+		arrays:  make(map[string]bool),
+	}
+	p.current = p.root
+	for {
+		p.skipBlank()
+		if p.eof() {
+			return p.root, nil
+		}
+		if p.peek() == '[' {
+			if err := p.parseTableHeader(); err != nil {
+				return nil, err
+			}
+		} else {
+			keys, err := p.parseKeyValue(p.current)
+			if err != nil {
+				return nil, err
+			}
+			// 点分隔的键定义的表不能再通过 [table] 定义
+			path := append(p.path[:len(p.path):len(p.path)], keys...)
+			for i := len(p.path) + 1; i < len(path); i++ {
+				p.defined[tomlName(path[:i])] = true
+			}
+		}
+		if err := p.endLine(); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// UnmarshalToml 解析 TOML 格式的配置，配置字段的名称与 yaml 格式相同
+func UnmarshalToml(data []byte, c interface{}) error {
+	table, err := parseToml(data)
+	if err != nil {
+		return err
+	}
+	node := yaml.Node{}
+	if err := node.Encode(table); err != nil {
+		return err
+	}
+	return node.Decode(c)
+}
@@ -1,10 +1,12 @@
 package config
 
 import (
+	"bytes"
 	"encoding/json"
 	"gitee.com/sy_183/common/id"
 	"gopkg.in/yaml.v3"
 	"strings"
+	"sync"
 )
 
 type Type struct {
@@ -12,6 +14,9 @@ type Type struct {
 	Name        string
 	Suffixes    []string
 	Unmarshaler func([]byte, interface{}) error
+
+	// Sniffer 根据配置的内容判断配置是否为此类型，用于确定没有可识别后缀的配置文件的类型
+	Sniffer func([]byte) bool
 }
 
 var typeIdCxt uint64
@@ -28,18 +33,108 @@ func NewType(Name string, Suffixes []string, Unmarshaler func([]byte, interface{
 var (
 	TypeUnknown = Type{Id: id.Uint64Id(&typeIdCxt), Name: "unknown"}
 	TypeYaml    = Type{Id: id.Uint64Id(&typeIdCxt), Name: "yaml", Suffixes: []string{"yaml", "yml"}, Unmarshaler: yaml.Unmarshal}
-	TypeJson    = Type{Id: id.Uint64Id(&typeIdCxt), Name: "json", Suffixes: []string{"json"}, Unmarshaler: json.Unmarshal}
+	TypeJson    = Type{Id: id.Uint64Id(&typeIdCxt), Name: "json", Suffixes: []string{"json"}, Unmarshaler: json.Unmarshal, Sniffer: sniffJson}
+	TypeToml    = Type{Id: id.Uint64Id(&typeIdCxt), Name: "toml", Suffixes: []string{"toml"}, Unmarshaler: UnmarshalToml, Sniffer: sniffToml}
+	TypeIni     = Type{Id: id.Uint64Id(&typeIdCxt), Name: "ini", Suffixes: []string{"ini"}, Unmarshaler: UnmarshalIni, Sniffer: sniffIni}
+	TypeDotenv  = Type{Id: id.Uint64Id(&typeIdCxt), Name: "dotenv", Suffixes: []string{"env"}, Unmarshaler: UnmarshalDotenv, Sniffer: sniffDotenv}
+)
+
+var (
+	// 内容判断时按照此顺序依次判断，dotenv 格式的内容也可能是合法的 TOML，所以需要在 TOML
+	// 之前判断
+	types       = []Type{TypeYaml, TypeJson, TypeDotenv, TypeToml, TypeIni}
+	typesLocker sync.RWMutex
 )
 
-var types = []*Type{&TypeYaml, &TypeJson}
+// RegisterType 注册配置类型，注册后 ProbeType 可以根据后缀识别此类型，SniffType 可以根据
+// 内容识别此类型。如果已经注册了相同 Id 的类型，则替换原来的类型。多个类型包含相同的后缀
+// 时，先注册的类型优先
+func RegisterType(typ Type) {
+	typesLocker.Lock()
+	defer typesLocker.Unlock()
+	for i := range types {
+		if types[i].Id == typ.Id {
+			types[i] = typ
+			return
+		}
+	}
+	types = append(types, typ)
+}
+
+// Types 返回所有已注册的配置类型
+func Types() []Type {
+	typesLocker.RLock()
+	defer typesLocker.RUnlock()
+	return append([]Type(nil), types...)
+}
 
 func ProbeType(path string) Type {
-	for _, tpy := range types {
+	for _, tpy := range Types() {
 		for _, suffix := range tpy.Suffixes {
 			if strings.HasSuffix(path, "."+suffix) {
-				return *tpy
+				return tpy
 			}
 		}
 	}
 	return TypeUnknown
 }
+
+// SniffType 根据配置的内容判断配置的类型，依次使用已注册类型的 Sniffer 判断，都不符合时
+// 作为 yaml 格式
+func SniffType(data []byte) Type {
+	data = bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\uFEFF")))
+	for _, typ := range Types() {
+		if typ.Sniffer != nil && typ.Sniffer(data) {
+			return typ
+		}
+	}
+	return TypeYaml
+}
+
+func sniffJson(data []byte) bool {
+	return len(data) > 0 && (data[0] == '{' || data[0] == '[') && json.Valid(data)
+}
+
+func sniffToml(data []byte) bool {
+	table, err := parseToml(data)
+	return err == nil && len(table) > 0
+}
+
+// sniffIni 判断内容是否为 INI 格式，需要至少包含一个节，并且键值对都使用等号分隔，避免
+// 将 yaml 格式的内容识别为 INI 格式
+func sniffIni(data []byte) bool {
+	if _, err := parseIni(data); err != nil {
+		return false
+	}
+	var section bool
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+		case line[0] == '[':
+			if !strings.HasSuffix(line, "]") {
+				return false
+			}
+			section = true
+		case strings.IndexAny(line, "=:") != strings.IndexByte(line, '='):
+			return false
+		}
+	}
+	return section
+}
+
+// sniffDotenv 判断内容是否为 dotenv 格式，所有变量的名称都必须为大写字母、数字和下划线
+func sniffDotenv(data []byte) bool {
+	vars, err := parseDotenv(data)
+	if err != nil || len(vars) == 0 {
+		return false
+	}
+	for key := range vars {
+		for i, c := range key {
+			if !(c >= 'A' && c <= 'Z' || c == '_' || i > 0 && c >= '0' && c <= '9') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,325 @@
+package config
+
+import (
+	"gitee.com/sy_183/common/unit"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type formatConfig struct {
+	Name   string `yaml:"name"`
+	Server struct {
+		Host    string        `yaml:"host"`
+		Port    int           `yaml:"port"`
+		Timeout time.Duration `yaml:"timeout"`
+		Enable  bool          `yaml:"enable"`
+	} `yaml:"server"`
+	MaxSize unit.Size `yaml:"max-size"`
+	Tags    []string  `yaml:"tags"`
+}
+
+func (c *formatConfig) check(t *testing.T, format string) {
+	if c.Name != "test" || c.Server.Host != "127.0.0.1" || c.Server.Port != 8080 ||
+		c.Server.Timeout != time.Second*3 || !c.Server.Enable || c.MaxSize != 4*unit.MeBiByte {
+		t.Errorf("%s: unexpected config %+v", format, c)
+	}
+}
+
+const (
+	tomlConfig = `# TOML
+name = "test"
+max-size = "4MiB"
+tags = [
+  "a", # 注释
+  'b',
+]
+
+[server]
+host = "127.0.0.1"
+port = 8_080
+timeout = "3s"
+enable = true
+`
+	iniConfig = `; INI
+name = test
+max-size = 4MiB
+
+[server]
+host = 127.0.0.1
+port = 8080 ; 端口
+timeout = 3s
+enable = true
+`
+	dotenvConfig = `# dotenv
+export NAME=test
+MAX_SIZE=4MiB
+SERVER__HOST="127.0.0.1"
+SERVER__PORT=8080
+SERVER__TIMEOUT='3s'
+SERVER__ENABLE=true
+TAGS="[a,
+ b]"
+`
+)
+
+func TestParseToml(t *testing.T) {
+	table, err := parseToml([]byte(`
+int = [0x1F, 0o17, 0b11, -1_000]
+float = [1.5, 1e3, -inf]
+str = """
+line1 \
+  line2"""
+literal = '''C:\path'''
+date = 1979-05-27
+datetime = 1979-05-27 07:32:00Z
+inline = {a.b = 1, c = [{d = 2}]}
+
+[[items]]
+name = "a"
+[[items]]
+name = "b"
+[a.b.c]
+"quoted key" = 'v'
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ints := table["int"].([]any)
+	if ints[0] != int64(31) || ints[1] != int64(15) || ints[2] != int64(3) || ints[3] != int64(-1000) {
+		t.Errorf("unexpected ints %v", ints)
+	}
+	if table["str"] != "line1 line2" || table["literal"] != `C:\path` {
+		t.Errorf("unexpected strings %q %q", table["str"], table["literal"])
+	}
+	if d, is := table["datetime"].(time.Time); !is || d.Hour() != 7 {
+		t.Errorf("unexpected datetime %v", table["datetime"])
+	}
+	if items := table["items"].([]any); len(items) != 2 || items[1].(map[string]any)["name"] != "b" {
+		t.Errorf("unexpected array of tables %v", items)
+	}
+	if table["a"].(map[string]any)["b"].(map[string]any)["c"].(map[string]any)["quoted key"] != "v" {
+		t.Errorf("unexpected nested table %v", table["a"])
+	}
+
+	for _, invalid := range []string{"a = ", "a = 1\na = 2", "[t]\n[t]", "a = 1 b = 2", "a = \"unterminated", "a = 01"} {
+		if _, err := parseToml([]byte(invalid)); err == nil {
+			t.Errorf("invalid toml %q should fail", invalid)
+		}
+	}
+}
+
+func TestTomlConformance(t *testing.T) {
+	// 日期时间
+	table, err := parseToml([]byte(`
+odt1 = 1979-05-27T07:32:00Z
+odt2 = 1979-05-27T00:32:00.999999-07:00
+odt3 = 1979-05-27 07:32:00Z
+odt4 = 1979-05-27t07:32:00z
+ldt = 1979-05-27T07:32:00.5
+ld = 1979-05-27 # 本地日期
+lt = 00:32:00.999999
+dates = [1979-05-27, 1979-05-28]
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	utc := time.Date(1979, 5, 27, 7, 32, 0, 0, time.UTC)
+	for _, key := range []string{"odt1", "odt3", "odt4"} {
+		if d, is := table[key].(time.Time); !is || !d.Equal(utc) {
+			t.Errorf("%s: expect %s, got %v", key, utc, table[key])
+		}
+	}
+	if d, is := table["odt2"].(time.Time); !is || !d.Equal(utc.Add(time.Microsecond*999999)) {
+		t.Errorf("odt2: unexpected offset datetime %v", table["odt2"])
+	}
+	if d, is := table["ldt"].(time.Time); !is || d.Nanosecond() != 5e8 || d.Hour() != 7 {
+		t.Errorf("ldt: unexpected local datetime %v", table["ldt"])
+	}
+	if d, is := table["ld"].(time.Time); !is || d.Day() != 27 || d.Hour() != 0 {
+		t.Errorf("ld: unexpected local date %v", table["ld"])
+	}
+	if table["lt"] != "00:32:00.999999" {
+		t.Errorf("lt: unexpected local time %v", table["lt"])
+	}
+	if dates := table["dates"].([]any); len(dates) != 2 || dates[1].(time.Time).Day() != 28 {
+		t.Errorf("unexpected dates %v", dates)
+	}
+
+	// 表数组，每个表中可以重新定义子表和嵌套的表数组
+	table, err = parseToml([]byte(`
+[[fruits]]
+name = "apple"
+[fruits.physical]
+color = "red"
+[[fruits.varieties]]
+name = "red delicious"
+[[fruits.varieties]]
+name = "granny smith"
+
+[[fruits]]
+name = "banana"
+[fruits.physical]
+color = "yellow"
+[[fruits.varieties]]
+name = "plantain"
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fruits := table["fruits"].([]any)
+	if len(fruits) != 2 {
+		t.Fatalf("expect 2 fruits, got %v", fruits)
+	}
+	apple, banana := fruits[0].(map[string]any), fruits[1].(map[string]any)
+	if apple["physical"].(map[string]any)["color"] != "red" || len(apple["varieties"].([]any)) != 2 {
+		t.Errorf("unexpected apple %v", apple)
+	}
+	if banana["physical"].(map[string]any)["color"] != "yellow" || len(banana["varieties"].([]any)) != 1 {
+		t.Errorf("unexpected banana %v", banana)
+	}
+
+	// 点分隔的键
+	table, err = parseToml([]byte(`
+name.first = "Tom"
+site."google.com" = true
+a . b . c = 1
+a.b.d = 2
+
+[fruit]
+apple.color = "red"
+apple.taste.sweet = true
+[fruit.apple.texture]
+smooth = true
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if table["name"].(map[string]any)["first"] != "Tom" || table["site"].(map[string]any)["google.com"] != true {
+		t.Errorf("unexpected dotted keys %v", table)
+	}
+	if b := table["a"].(map[string]any)["b"].(map[string]any); b["c"] != int64(1) || b["d"] != int64(2) {
+		t.Errorf("unexpected dotted keys %v", b)
+	}
+	apple = table["fruit"].(map[string]any)["apple"].(map[string]any)
+	if apple["taste"].(map[string]any)["sweet"] != true || apple["texture"].(map[string]any)["smooth"] != true {
+		t.Errorf("unexpected dotted keys %v", apple)
+	}
+
+	for _, invalid := range []string{
+		"[[a]]\n[a]",
+		"a = [1]\n[[a]]",
+		"a = 1\na.b = 2",
+		"[fruit]\napple.color = 1\n[fruit.apple]",
+		"a.b = 1\n[a.b]",
+		"[[a]]\n[a.b]\n[a.b]",
+	} {
+		if _, err := parseToml([]byte(invalid)); err == nil {
+			t.Errorf("invalid toml %q should fail", invalid)
+		}
+	}
+
+	// 日期时间可以解析到配置中的 time.Time 类型的字段
+	var c struct {
+		Start time.Time `yaml:"start"`
+		Items []struct {
+			Name string `yaml:"name"`
+		} `yaml:"items"`
+	}
+	if err := UnmarshalToml([]byte("start = 1979-05-27T07:32:00Z\n[[items]]\nname = \"a\"\n[[items]]\nname = \"b\"\n"), &c); err != nil {
+		t.Fatal(err)
+	}
+	if !c.Start.Equal(utc) || len(c.Items) != 2 || c.Items[1].Name != "b" {
+		t.Errorf("unexpected config %+v", c)
+	}
+}
+
+func TestFormats(t *testing.T) {
+	for _, typ := range []Type{TypeToml, TypeIni, TypeDotenv} {
+		var data string
+		switch typ.Id {
+		case TypeToml.Id:
+			data = tomlConfig
+		case TypeIni.Id:
+			data = iniConfig
+		case TypeDotenv.Id:
+			data = dotenvConfig
+		}
+		c := new(formatConfig)
+		if err := typ.Unmarshaler([]byte(data), c); err != nil {
+			t.Fatalf("%s: %v", typ.Name, err)
+		}
+		c.check(t, typ.Name)
+		if typ.Id != TypeIni.Id && strings.Join(c.Tags, ",") != "a,b" {
+			t.Errorf("%s: unexpected tags %v", typ.Name, c.Tags)
+		}
+		if sniffed := SniffType([]byte(data)); sniffed.Id != typ.Id {
+			t.Errorf("%s content sniffed as %s", typ.Name, sniffed.Name)
+		}
+	}
+	for data, expect := range map[string]Type{
+		`{"name": "test"}`:            TypeJson,
+		"name: test\nurl: http://a=b": TypeYaml,
+		"- a\n- b":                    TypeYaml,
+	} {
+		if sniffed := SniffType([]byte(data)); sniffed.Id != expect.Id {
+			t.Errorf("%q should be sniffed as %s, got %s", data, expect.Name, sniffed.Name)
+		}
+	}
+}
+
+func TestRegisterType(t *testing.T) {
+	custom := NewType("custom", []string{"custom"}, func(data []byte, c interface{}) error {
+		c.(*formatConfig).Name = string(data)
+		return nil
+	})
+	custom.Sniffer = func(data []byte) bool { return strings.HasPrefix(string(data), "custom:") }
+	RegisterType(custom)
+	if typ := ProbeType("app.custom"); typ.Name != custom.Name {
+		t.Errorf("registered type should be probed, got %s", typ.Name)
+	}
+	if typ := ProbeType(".env"); typ.Id != TypeDotenv.Id {
+		t.Errorf(".env should be probed as dotenv, got %s", typ.Name)
+	}
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "app.custom"), []byte("custom"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// 没有指定类型时只查找 yaml 和 json 格式的文件
+	p := Parser{}
+	p.AddFilePrefix(filepath.Join(dir, "app"))
+	c := new(formatConfig)
+	if err := p.Unmarshal(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "" {
+		t.Errorf("file prefix without types should not find registered type, got %+v", c)
+	}
+	p.SetFilePrefix(filepath.Join(dir, "app"), Types()...)
+	if err := p.Unmarshal(c); err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "custom" {
+		t.Errorf("file prefix should find registered type, got %+v", c)
+	}
+
+	// 没有可识别后缀的配置文件根据内容判断类型
+	path := filepath.Join(dir, "app.conf")
+	if err := os.WriteFile(path, []byte(tomlConfig), 0644); err != nil {
+		t.Fatal(err)
+	}
+	p = Parser{}
+	p.AddFile(path, nil)
+	c = new(formatConfig)
+	if err := p.Unmarshal(c); err != nil {
+		t.Fatal(err)
+	}
+	c.check(t, "sniffed toml")
+	p.SetBytes([]byte("custom:value"), TypeUnknown)
+	if err := p.Unmarshal(c); err != nil || c.Name != "custom:value" {
+		t.Errorf("registered sniffer should be used, got %+v, %v", c, err)
+	}
+}
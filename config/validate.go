@@ -0,0 +1,343 @@
+package config
+
+import (
+	"fmt"
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/unit"
+	"net"
+	"net/url"
+	"os"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ValidateError 为配置项不满足 validate 标签中的规则时返回的错误，Field 为配置项在配置
+// 文件中的完整路径，例如 server.tls.cert-file
+type ValidateError struct {
+	Field string
+	Rule  string
+	Err   error
+}
+
+func (e *ValidateError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("配置项(%s)校验失败(%s): %s", e.Field, e.Rule, e.Err.Error())
+}
+
+func (e *ValidateError) Unwrap() error {
+	return e.Err
+}
+
+var sizeType = reflect.TypeOf(unit.Size(0))
+
+type validateRule struct {
+	name  string
+	param string
+}
+
+func (r validateRule) String() string {
+	if r.param == "" {
+		return r.name
+	}
+	return r.name + "=" + r.param
+}
+
+// parseRules 解析 validate 标签，规则之间使用逗号分隔，规则的参数使用等号指定。因为正则
+// 表达式中可能包含逗号，regex 规则之后的内容都作为正则表达式
+func parseRules(tag string) (rules []validateRule) {
+	for tag != "" {
+		var item string
+		if strings.HasPrefix(tag, "regex=") {
+			item, tag = tag, ""
+		} else {
+			item, tag, _ = strings.Cut(tag, ",")
+		}
+		name, param, _ := strings.Cut(strings.TrimSpace(item), "=")
+		if name != "" {
+			rules = append(rules, validateRule{name: name, param: param})
+		}
+	}
+	return
+}
+
+var regexps sync.Map
+
+func compileRegexp(expr string) (*regexp.Regexp, error) {
+	if re, ok := regexps.Load(expr); ok {
+		return re.(*regexp.Regexp), nil
+	}
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return nil, err
+	}
+	regexps.Store(expr, re)
+	return re, nil
+}
+
+func unsupportedType(v reflect.Value) error {
+	return fmt.Errorf("不支持的配置项类型(%s)", v.Type())
+}
+
+func compare[T int | int64 | uint64 | float64](a, b T) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// compareBound 比较配置项与规则参数的大小，字符串、切片、映射和数组比较长度，
+// time.Duration 和 unit.Size 类型的规则参数分别使用时间间隔和大小的格式
+func compareBound(v reflect.Value, param string) (cmp int, err error) {
+	switch v.Type() {
+	case durationType:
+		bound, err := time.ParseDuration(param)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Int(), int64(bound)), nil
+	case sizeType:
+		var bound unit.Size
+		if err := bound.UnmarshalText([]byte(param)); err != nil {
+			return 0, err
+		}
+		return compare(v.Uint(), uint64(bound)), nil
+	}
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bound, err := strconv.ParseInt(param, 0, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Int(), bound), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		bound, err := strconv.ParseUint(param, 0, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Uint(), uint64(bound)), nil
+	case reflect.Float32, reflect.Float64:
+		bound, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return 0, err
+		}
+		return compare(v.Float(), bound), nil
+	}
+	length, err := valueLen(v)
+	if err != nil {
+		return 0, err
+	}
+	bound, err := strconv.Atoi(param)
+	if err != nil {
+		return 0, err
+	}
+	return compare(length, bound), nil
+}
+
+func valueLen(v reflect.Value) (int, error) {
+	switch v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), nil
+	case reflect.Slice, reflect.Map, reflect.Array:
+		return v.Len(), nil
+	}
+	return 0, unsupportedType(v)
+}
+
+func stringValue(v reflect.Value) (string, error) {
+	if v.Kind() != reflect.String {
+		return "", unsupportedType(v)
+	}
+	return v.String(), nil
+}
+
+// checkRule 检查配置项是否满足规则，配置项为空指针时只检查 required 规则
+func checkRule(v reflect.Value, rule validateRule) error {
+	if rule.name == "required" {
+		if v.IsZero() {
+			return errors.New("配置项不能为空")
+		}
+		return nil
+	}
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch rule.name {
+	case "min", "max":
+		cmp, err := compareBound(v, rule.param)
+		if err != nil {
+			return err
+		}
+		if rule.name == "min" && cmp < 0 {
+			return fmt.Errorf("配置项不能小于%s", rule.param)
+		} else if rule.name == "max" && cmp > 0 {
+			return fmt.Errorf("配置项不能大于%s", rule.param)
+		}
+	case "len":
+		length, err := valueLen(v)
+		if err != nil {
+			return err
+		}
+		if expect, err := strconv.Atoi(rule.param); err != nil {
+			return err
+		} else if length != expect {
+			return fmt.Errorf("配置项长度必须为%d，实际长度为%d", expect, length)
+		}
+	case "oneof":
+		s := fmt.Sprint(v.Interface())
+		options := strings.Fields(rule.param)
+		for _, option := range options {
+			if s == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("配置项(%s)必须为[%s]中的一个", s, strings.Join(options, ", "))
+	case "regex":
+		s, err := stringValue(v)
+		if err != nil {
+			return err
+		}
+		re, err := compileRegexp(rule.param)
+		if err != nil {
+			return err
+		}
+		if !re.MatchString(s) {
+			return fmt.Errorf("配置项(%s)不匹配正则表达式(%s)", s, rule.param)
+		}
+	case "url":
+		s, err := stringValue(v)
+		if err != nil {
+			return err
+		}
+		u, err := url.Parse(s)
+		if err != nil {
+			return err
+		}
+		if u.Scheme == "" || (u.Host == "" && u.Opaque == "" && u.Path == "") {
+			return fmt.Errorf("配置项(%s)不是有效的URL", s)
+		}
+	case "hostport":
+		s, err := stringValue(v)
+		if err != nil {
+			return err
+		}
+		_, port, err := net.SplitHostPort(s)
+		if err != nil {
+			return err
+		}
+		if _, err := strconv.ParseUint(port, 10, 16); err != nil {
+			return fmt.Errorf("配置项(%s)中的端口无效", s)
+		}
+	case "file-exists":
+		s, err := stringValue(v)
+		if err != nil {
+			return err
+		}
+		if _, err := os.Stat(s); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("未知的校验规则(%s)", rule.name)
+	}
+	return nil
+}
+
+type validator struct {
+	errs    errors.Errors
+	visited map[any]struct{}
+}
+
+func (vd *validator) check(v reflect.Value, path string, rules []validateRule) {
+	for _, rule := range rules {
+		if rule.name == "omitempty" {
+			if v.IsZero() {
+				return
+			}
+			continue
+		}
+		if err := checkRule(v, rule); err != nil {
+			vd.errs = vd.errs.Append(&ValidateError{Field: path, Rule: rule.String(), Err: err})
+		}
+	}
+}
+
+func (vd *validator) validate(v reflect.Value, path string) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Ptr && v.CanInterface() {
+			c := v.Interface()
+			if _, repeat := vd.visited[c]; repeat {
+				return
+			}
+			vd.visited[c] = struct{}{}
+		}
+		vd.validate(v.Elem(), path)
+	case reflect.Struct:
+		vt := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := vt.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			name, inline, skip := fieldName(field)
+			if skip {
+				continue
+			}
+			fpath := path
+			if !inline {
+				if path == "" {
+					fpath = name
+				} else {
+					fpath = path + "." + name
+				}
+			}
+			fv := v.Field(i)
+			if tag, has := field.Tag.Lookup("validate"); has {
+				vd.check(fv, fpath, parseRules(tag))
+			}
+			vd.validate(fv, fpath)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			vd.validate(v.Index(i), fmt.Sprintf("%s[%d]", path, i))
+		}
+	case reflect.Map:
+		for iter := v.MapRange(); iter.Next(); {
+			vd.validate(iter.Value(), fmt.Sprintf("%s[%v]", path, iter.Key()))
+		}
+	}
+}
+
+// Validate 根据配置结构体字段的 validate 标签校验配置，检查所有的配置项，所有不满足规则
+// 的配置项产生的 ValidateError 合并为 errors.Errors 返回。支持的规则如下:
+//
+//	required           配置项不能为零值
+//	omitempty          配置项为零值时不检查之后的规则
+//	min=N, max=N       数值的范围，字符串、切片、映射和数组的长度范围，time.Duration 和
+//	                   unit.Size 类型的参数分别为时间间隔和大小，例如 min=1s，max=1GiB
+//	len=N              字符串、切片、映射和数组的长度
+//	oneof=A B C        配置项格式化后的字符串必须为空格分隔的选项中的一个
+//	regex=EXPR         字符串必须匹配正则表达式，必须为最后一个规则
+//	url                字符串必须为有效的URL
+//	hostport           字符串必须为 host:port 格式的地址
+//	file-exists        字符串指定的文件必须存在
+func Validate(c any) error {
+	vd := validator{visited: make(map[any]struct{})}
+	vd.validate(reflect.ValueOf(c), "")
+	return vd.errs.ToError()
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/unit"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type validateConfig struct {
+	Name   string `yaml:"name" validate:"required,len=4"`
+	Level  string `yaml:"level" default:"info" validate:"oneof=debug info warn error"`
+	Server struct {
+		Addr    string        `yaml:"addr" validate:"hostport"`
+		Port    int           `yaml:"port" validate:"min=1,max=65535"`
+		Timeout time.Duration `yaml:"timeout" validate:"min=1s,max=1m"`
+		TLS     *struct {
+			CertFile string `yaml:"cert-file" validate:"required,file-exists"`
+		} `yaml:"tls"`
+	} `yaml:"server"`
+	Upstreams []struct {
+		URL string `yaml:"url" validate:"url"`
+	} `yaml:"upstreams"`
+	MaxSize unit.Size `yaml:"max-size" validate:"max=1GiB"`
+	Tags    []string  `yaml:"tags" validate:"omitempty,min=1,max=2"`
+	ID      string    `yaml:"id" validate:"omitempty,regex=^[a-z]{1,3}$"`
+}
+
+func TestValidate(t *testing.T) {
+	dir := t.TempDir()
+	cert := filepath.Join(dir, "cert.pem")
+	if err := os.WriteFile(cert, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	p := Parser{}
+	p.AddBytes([]byte(`
+name: test
+server:
+  addr: 0.0.0.0:8080
+  port: 8080
+  timeout: 10s
+  tls: {cert-file: `+cert+`}
+upstreams: [{url: "http://127.0.0.1:8080/api"}]
+max-size: 1MiB
+id: ab
+`), TypeYaml)
+	if err := p.Unmarshal(new(validateConfig)); err != nil {
+		t.Fatalf("valid config should pass validation, got %v", err)
+	}
+
+	p.SetBytes([]byte(`
+name: tests
+level: trace
+server:
+  addr: 0.0.0.0
+  port: 0
+  timeout: 2m
+  tls: {cert-file: `+filepath.Join(dir, "not-exist.pem")+`}
+upstreams: [{url: "http://127.0.0.1"}, {url: "127.0.0.1"}]
+max-size: 2GiB
+tags: [a, b, c]
+id: "a,b"
+`), TypeYaml)
+	err := p.Unmarshal(new(validateConfig))
+	var es errors.Errors
+	if !errors.As(err, &es) {
+		t.Fatalf("expect aggregated errors, got %v", err)
+	}
+	fields := make(map[string]bool)
+	for _, e := range es {
+		var ve *ValidateError
+		if !errors.As(e, &ve) {
+			t.Fatalf("expect validate error, got %v", e)
+		}
+		fields[ve.Field] = true
+	}
+	for _, field := range []string{"name", "level", "server.addr", "server.port", "server.timeout",
+		"server.tls.cert-file", "upstreams[1].url", "max-size", "tags", "id"} {
+		if !fields[field] {
+			t.Errorf("field %s should fail validation", field)
+		}
+	}
+	if len(es) != 10 {
+		t.Errorf("expect 10 errors, got %d: %v", len(es), es)
+	}
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"gitee.com/sy_183/common/lifecycle"
+	"gitee.com/sy_183/common/option"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	DefaultWatchDebounce     = time.Millisecond * 100
+	DefaultWatchPollInterval = time.Second * 2
+)
+
+// fileNotifier 监听目录中文件的变化，目录中任意文件发生变化时向 Events 返回的通道发送
+// 通知，监听出错时关闭通道
+type fileNotifier interface {
+	Events() <-chan struct{}
+
+	// Watch 将监听的目录更新为 dirs，不在 dirs 中的目录不再监听
+	Watch(dirs []string)
+
+	Close() error
+}
+
+// reloader 为可以被 Watcher 监听并重新加载的配置
+type reloader interface {
+	Files() []string
+	ReloadConfig()
+}
+
+// fileState 为配置文件的状态，用于判断配置文件是否发生了变化。状态通过 os.Stat 获取，
+// 所以符号链接指向的文件被替换时状态也会发生变化
+type fileState struct {
+	info os.FileInfo
+}
+
+func statFiles(files []string) []fileState {
+	states := make([]fileState, len(files))
+	for i, file := range files {
+		if info, err := os.Stat(file); err == nil {
+			states[i].info = info
+		}
+	}
+	return states
+}
+
+func (s fileState) equal(o fileState) bool {
+	if s.info == nil || o.info == nil {
+		return s.info == nil && o.info == nil
+	}
+	return os.SameFile(s.info, o.info) &&
+		s.info.Size() == o.info.Size() &&
+		s.info.Mode() == o.info.Mode() &&
+		s.info.ModTime().Equal(o.info.ModTime())
+}
+
+func statesEqual(s1, s2 []fileState) bool {
+	for i := range s1 {
+		if !s1[i].equal(s2[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// existDir 返回 dir 自身或最近的一个存在的上级目录，目录被创建时可以通过上级目录得到通知
+func existDir(dir string) string {
+	for {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return dir
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return dir
+		}
+		dir = parent
+	}
+}
+
+// watchDirs 返回需要监听的目录，包括配置文件所在的目录以及配置文件为符号链接时链接指向
+// 的文件所在的目录。原子替换(重命名)和 Kubernetes ConfigMap 的符号链接切换都发生在配
+// 置文件所在的目录中
+func watchDirs(files []string) []string {
+	set := make(map[string]struct{})
+	var dirs []string
+	add := func(dir string) {
+		dir = existDir(dir)
+		if _, has := set[dir]; !has {
+			set[dir] = struct{}{}
+			dirs = append(dirs, dir)
+		}
+	}
+	for _, file := range files {
+		if abs, err := filepath.Abs(file); err == nil {
+			file = abs
+		}
+		add(filepath.Dir(file))
+		if target, err := filepath.EvalSymlinks(file); err == nil && target != file {
+			add(filepath.Dir(target))
+		}
+	}
+	return dirs
+}
+
+// Watcher 监听配置中所有通过 AddFile 和 AddFilePrefix 添加的配置文件，配置文件发生变化
+// 时调用 ReloadConfig 重新加载配置，重新加载的检查和错误回调与手动调用 ReloadConfig
+// 时相同。Linux 平台使用 inotify 监听配置文件所在的目录，其他平台或 inotify 不可用时定
+// 时轮询配置文件的状态。配置文件连续的多次写入在防抖时间内只会触发一次重新加载
+type Watcher struct {
+	lifecycle.Lifecycle
+	reloader reloader
+
+	debounce     time.Duration
+	pollInterval time.Duration
+	forcePolling bool
+}
+
+func NewWatcher[C any](ctx *Context[C], options ...option.AnyOption) *Watcher {
+	w := &Watcher{
+		reloader:     ctx,
+		debounce:     DefaultWatchDebounce,
+		pollInterval: DefaultWatchPollInterval,
+	}
+	for _, opt := range options {
+		opt.Apply(w)
+	}
+	w.Lifecycle = lifecycle.NewWithInterruptedRun(nil, w.run)
+	return w
+}
+
+func (w *Watcher) setDebounce(debounce time.Duration) {
+	if debounce < 0 {
+		debounce = 0
+	}
+	w.debounce = debounce
+}
+
+func (w *Watcher) setPolling(interval time.Duration, force bool) {
+	if interval <= 0 {
+		interval = DefaultWatchPollInterval
+	}
+	w.pollInterval, w.forcePolling = interval, force
+}
+
+func (w *Watcher) run(_ lifecycle.Lifecycle, interrupter chan struct{}) error {
+	files := w.reloader.Files()
+	loaded := statFiles(files)
+	observed := loaded
+
+	var events <-chan struct{}
+	var notifier fileNotifier
+	if !w.forcePolling {
+		if n, err := newFileNotifier(); err == nil {
+			notifier = n
+			defer notifier.Close()
+			notifier.Watch(watchDirs(files))
+			events = notifier.Events()
+		}
+	}
+
+	var ticker *time.Ticker
+	var tick <-chan time.Time
+	startPolling := func() {
+		ticker = time.NewTicker(w.pollInterval)
+		tick = ticker.C
+	}
+	defer func() {
+		if ticker != nil {
+			ticker.Stop()
+		}
+	}()
+	if notifier == nil {
+		startPolling()
+	}
+
+	var debounce *time.Timer
+	var debounced <-chan time.Time
+	resetDebounce := func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+		debounce = time.NewTimer(w.debounce)
+		debounced = debounce.C
+	}
+	defer func() {
+		if debounce != nil {
+			debounce.Stop()
+		}
+	}()
+
+	for {
+		select {
+		case _, ok := <-events:
+			if !ok {
+				// inotify 出错，使用轮询继续监听
+				events = nil
+				startPolling()
+				continue
+			}
+			resetDebounce()
+		case <-tick:
+			if states := statFiles(files); !statesEqual(states, observed) {
+				observed = states
+				resetDebounce()
+			}
+		case <-debounced:
+			debounce, debounced = nil, nil
+			states := statFiles(files)
+			observed = states
+			if events != nil {
+				// 目录可能被创建或符号链接可能指向了新的目录，需要重新确定监听的目录
+				notifier.Watch(watchDirs(files))
+			}
+			if !statesEqual(states, loaded) {
+				loaded = states
+				w.reloader.ReloadConfig()
+			}
+		case <-interrupter:
+			return nil
+		}
+	}
+}
+
+type debounceSetter interface {
+	setDebounce(debounce time.Duration)
+}
+
+// WithDebounce 指定配置文件变化后重新加载配置前等待的时间，等待期间配置文件再次发生变化
+// 时重新计时，默认为 DefaultWatchDebounce
+func WithDebounce(debounce time.Duration) option.AnyOption {
+	return option.AnyCustom(func(target any) {
+		if setter, is := target.(debounceSetter); is {
+			setter.setDebounce(debounce)
+		}
+	})
+}
+
+type pollingSetter interface {
+	setPolling(interval time.Duration, force bool)
+}
+
+// WithPollInterval 指定无法使用 inotify 时轮询配置文件状态的间隔，默认为
+// DefaultWatchPollInterval
+func WithPollInterval(interval time.Duration) option.AnyOption {
+	return option.AnyCustom(func(target any) {
+		if setter, is := target.(pollingSetter); is {
+			setter.setPolling(interval, false)
+		}
+	})
+}
+
+// WithPolling 指定总是使用轮询的方式监听配置文件，适用于 inotify 无法正常工作的文件系
+// 统，例如部分网络文件系统
+func WithPolling(interval time.Duration) option.AnyOption {
+	return option.AnyCustom(func(target any) {
+		if setter, is := target.(pollingSetter); is {
+			setter.setPolling(interval, true)
+		}
+	})
+}
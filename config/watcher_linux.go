@@ -0,0 +1,74 @@
+package config
+
+import (
+	"os"
+	"syscall"
+)
+
+const inotifyMask = syscall.IN_MODIFY | syscall.IN_ATTRIB | syscall.IN_CLOSE_WRITE |
+	syscall.IN_MOVED_FROM | syscall.IN_MOVED_TO | syscall.IN_CREATE | syscall.IN_DELETE |
+	syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF
+
+type inotifyNotifier struct {
+	fd      int
+	file    *os.File
+	watches map[string]int
+	events  chan struct{}
+}
+
+func newFileNotifier() (fileNotifier, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, os.NewSyscallError("inotify_init1", err)
+	}
+	n := &inotifyNotifier{
+		fd: fd,
+		// 非阻塞的文件描述符会注册到运行时的网络轮询器中，关闭文件时阻塞的读取会立即返回
+		file:    os.NewFile(uintptr(fd), "inotify"),
+		watches: make(map[string]int),
+		events:  make(chan struct{}, 1),
+	}
+	go n.read()
+	return n, nil
+}
+
+func (n *inotifyNotifier) read() {
+	defer close(n.events)
+	buf := make([]byte, 4096)
+	for {
+		// 只需要知道目录中发生了变化，具体的事件内容不需要解析
+		if _, err := n.file.Read(buf); err != nil {
+			return
+		}
+		select {
+		case n.events <- struct{}{}:
+		default:
+		}
+	}
+}
+
+func (n *inotifyNotifier) Events() <-chan struct{} {
+	return n.events
+}
+
+func (n *inotifyNotifier) Watch(dirs []string) {
+	set := make(map[string]struct{}, len(dirs))
+	for _, dir := range dirs {
+		set[dir] = struct{}{}
+		// 重复添加相同的目录会返回相同的 watch descriptor，目录被删除后重新创建时需要重
+		// 新添加
+		if wd, err := syscall.InotifyAddWatch(n.fd, dir, inotifyMask); err == nil {
+			n.watches[dir] = wd
+		}
+	}
+	for dir, wd := range n.watches {
+		if _, has := set[dir]; !has {
+			syscall.InotifyRmWatch(n.fd, uint32(wd))
+			delete(n.watches, dir)
+		}
+	}
+}
+
+func (n *inotifyNotifier) Close() error {
+	return n.file.Close()
+}
@@ -0,0 +1,9 @@
+//go:build !linux
+
+package config
+
+import "gitee.com/sy_183/common/errors"
+
+func newFileNotifier() (fileNotifier, error) {
+	return nil, errors.New("当前平台不支持监听文件变化")
+}
@@ -0,0 +1,116 @@
+package config
+
+import (
+	"gitee.com/sy_183/common/option"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+type watchConfig struct {
+	Name string `yaml:"name"`
+}
+
+func writeFile(t *testing.T, path string, content string) {
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWatcher(t *testing.T) {
+	for _, polling := range []bool{false, true} {
+		var options []option.AnyOption
+		if polling {
+			options = append(options, WithPolling(time.Millisecond*20))
+		}
+		options = append(options, WithDebounce(time.Millisecond*50))
+
+		dir := t.TempDir()
+		path := filepath.Join(dir, "app.yaml")
+		writeFile(t, path, "name: init\n")
+		reloaded := make(chan string, 16)
+		var errs []*Error
+		ctx := NewContext[watchConfig](AddFilePrefix[watchConfig](filepath.Join(dir, "app"), TypeYaml),
+			ErrorCallback[watchConfig](func(err *Error) { errs = append(errs, err) }))
+		ctx.RegisterConfigReloadChecker(func(oc, nc *watchConfig) error {
+			if nc.Name == "" {
+				return os.ErrInvalid
+			}
+			return nil
+		})
+		ctx.RegisterConfigReloadedCallback(func(oc, nc *watchConfig) { reloaded <- nc.Name })
+		if ctx.Config().Name != "init" {
+			t.Fatalf("unexpected config %+v", ctx.Config())
+		}
+		<-reloaded
+
+		watcher := NewWatcher(ctx, options...)
+		if err := watcher.Start(); err != nil {
+			t.Fatal(err)
+		}
+		expect := func(name string) {
+			select {
+			case n := <-reloaded:
+				if n != name {
+					t.Errorf("polling=%t: expect reloaded config %s, got %s", polling, name, n)
+				}
+			case <-time.After(time.Second * 3):
+				t.Fatalf("polling=%t: config %s not reloaded", polling, name)
+			}
+		}
+
+		// 编辑器连续多次写入只触发一次重新加载
+		for i := 0; i < 5; i++ {
+			writeFile(t, path, "name: burst\n")
+			time.Sleep(time.Millisecond * 5)
+		}
+		expect("burst")
+
+		// 原子替换
+		tmp := filepath.Join(dir, ".app.yaml.tmp")
+		writeFile(t, tmp, "name: renamed\n")
+		if err := os.Rename(tmp, path); err != nil {
+			t.Fatal(err)
+		}
+		expect("renamed")
+
+		// Kubernetes ConfigMap 风格的符号链接切换
+		for _, version := range []string{"v1", "v2"} {
+			data := filepath.Join(dir, "..data_"+version)
+			if err := os.Mkdir(data, 0755); err != nil {
+				t.Fatal(err)
+			}
+			writeFile(t, filepath.Join(data, "app.yaml"), "name: "+version+"\n")
+			link := filepath.Join(dir, "..data_tmp")
+			if err := os.Symlink(filepath.Base(data), link); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Rename(link, filepath.Join(dir, "..data")); err != nil {
+				t.Fatal(err)
+			}
+			if version == "v1" {
+				if err := os.Remove(path); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.Symlink(filepath.Join("..data", "app.yaml"), path); err != nil {
+					t.Fatal(err)
+				}
+			}
+			expect(version)
+		}
+
+		// 重新加载检查失败时保留原配置
+		writeFile(t, path, "name: \n")
+		time.Sleep(time.Millisecond * 300)
+		if err := watcher.Shutdown(); err != nil {
+			t.Fatal(err)
+		}
+		if ctx.Config().Name != "v2" {
+			t.Errorf("polling=%t: config should not be replaced by invalid config, got %+v", polling, ctx.Config())
+		}
+		if len(errs) != 1 || errs[0].Type != ReloadCheckError {
+			t.Errorf("polling=%t: expect one reload check error, got %v", polling, errs)
+		}
+	}
+}
@@ -163,6 +163,7 @@ func (l *LinkedList[E]) RemoveTail() (entry *LinkedListEntry[E]) {
 	}
 	entry = l.tail.prev
 	l.removeLink(entry)
+	l.len--
 	return
 }
 
@@ -172,6 +173,7 @@ func (l *LinkedList[E]) RemoveHead() (entry *LinkedListEntry[E]) {
 	}
 	entry = l.head.next
 	l.removeLink(entry)
+	l.len--
 	return
 }
 
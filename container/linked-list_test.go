@@ -0,0 +1,21 @@
+package container
+
+import "testing"
+
+func TestLinkedListLen(t *testing.T) {
+	l := NewLinkedList[int]()
+	for i := 0; i < 4; i++ {
+		l.AddTail(i)
+	}
+	if entry := l.RemoveHead(); entry == nil || entry.Value() != 0 || l.Len() != 3 {
+		t.Fatalf("expect len 3 after remove head, got %d", l.Len())
+	}
+	if entry := l.RemoveTail(); entry == nil || entry.Value() != 3 || l.Len() != 2 {
+		t.Fatalf("expect len 2 after remove tail, got %d", l.Len())
+	}
+	l.RemoveHead()
+	l.RemoveTail()
+	if l.Len() != 0 || l.RemoveHead() != nil || l.RemoveTail() != nil || l.Len() != 0 {
+		t.Fatalf("expect empty list, got len %d", l.Len())
+	}
+}
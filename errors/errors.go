@@ -32,6 +32,32 @@ func (es Errors) Error() string {
 	return strings.Join(ess, ", ")
 }
 
+// Unwrap 返回所有的错误，使 errors.Is 和 errors.As 可以匹配其中任意一个错误
+func (es Errors) Unwrap() []error {
+	return es
+}
+
+// Is 判断其中任意一个错误是否与 target 匹配，Go 1.20 之前的 errors.Is 不支持
+// Unwrap() []error，通过此方法同样可以匹配
+func (es Errors) Is(target error) bool {
+	for _, err := range es {
+		if Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As 查找第一个可以赋值给 target 的错误，与 Is 相同，用于支持 Go 1.20 之前的 errors.As
+func (es Errors) As(target any) bool {
+	for _, err := range es {
+		if As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
 func (es Errors) ToError() error {
 	if len(es) == 0 {
 		return nil
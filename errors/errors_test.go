@@ -10,3 +10,15 @@ func TestErrors(t *testing.T) {
 	e := New("hello")
 	fmt.Println(arr.Error(), e)
 }
+
+func TestErrorsIs(t *testing.T) {
+	first, second := New("first"), StringError("second")
+	err := fmt.Errorf("wrapped: %w", Errors{first, Errors{second}})
+	if !Is(err, first) || !Is(err, second) || Is(err, New("other")) {
+		t.Errorf("errors.Is should match any error in %v", err)
+	}
+	var target StringError
+	if !As(err, &target) || target != second {
+		t.Errorf("errors.As should find %q in %v, got %q", second, err, target)
+	}
+}
@@ -0,0 +1,185 @@
+package lifecycle
+
+import (
+	"context"
+	"gitee.com/sy_183/common/lock"
+	"gitee.com/sy_183/common/utils"
+)
+
+// ContextRunner 与 InterruptedRunner 类似，但使用 context.Context 代替中断信号。传入
+// 的上下文即为组件的 Context()，在组件开始关闭时被取消
+type ContextRunner interface {
+	DoStart(ctx context.Context, lifecycle Lifecycle) error
+
+	DoRun(ctx context.Context, lifecycle Lifecycle) error
+}
+
+type (
+	ContextStartFunc = func(ctx context.Context, lifecycle Lifecycle) error
+	ContextRunFunc   = func(ctx context.Context, lifecycle Lifecycle) error
+)
+
+func ContextHoldRun(ctx context.Context, _ Lifecycle) error {
+	<-ctx.Done()
+	return nil
+}
+
+type contextRunnerFunc struct {
+	startFn ContextStartFunc
+	runFn   ContextRunFunc
+}
+
+func FuncContextRunner(startFn ContextStartFunc, runFn ContextRunFunc) ContextRunner {
+	if startFn == nil {
+		startFn = func(ctx context.Context, lifecycle Lifecycle) error { return nil }
+	}
+	if runFn == nil {
+		runFn = func(ctx context.Context, lifecycle Lifecycle) error { return nil }
+	}
+	return contextRunnerFunc{startFn: startFn, runFn: runFn}
+}
+
+func (f contextRunnerFunc) DoStart(ctx context.Context, lifecycle Lifecycle) error {
+	return f.startFn(ctx, lifecycle)
+}
+
+func (f contextRunnerFunc) DoRun(ctx context.Context, lifecycle Lifecycle) error {
+	return f.runFn(ctx, lifecycle)
+}
+
+type contextRunner struct {
+	canInterrupted canInterrupted
+	runner         ContextRunner
+}
+
+func newContextRunner(canInterrupted canInterrupted, runner ContextRunner) *contextRunner {
+	return &contextRunner{canInterrupted: canInterrupted, runner: runner}
+}
+
+func (r *contextRunner) DoStart(lifecycle Lifecycle) error {
+	return r.runner.DoStart(GetContext(lifecycle), lifecycle)
+}
+
+func (r *contextRunner) DoRun(lifecycle Lifecycle) error {
+	return r.runner.DoRun(GetContext(lifecycle), lifecycle)
+}
+
+func (r *contextRunner) DoClose(Lifecycle) error {
+	// 组件的上下文在状态切换为关闭中后被取消
+	r.canInterrupted.ToClosing()
+	return nil
+}
+
+var canceledContext = func() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}()
+
+// ContextProvider 由可以提供本次运行的上下文的生命周期组件实现
+type ContextProvider interface {
+	Context() context.Context
+}
+
+// GetContext 获取组件本次运行的上下文，组件没有实现 ContextProvider 时返回
+// context.Background()
+func GetContext(lifecycle Lifecycle) context.Context {
+	if provider, ok := lookup[ContextProvider](lifecycle); ok {
+		return provider.Context()
+	}
+	return context.Background()
+}
+
+// Context 返回组件本次运行的上下文，此上下文在组件开始启动时创建，在组件开始关闭时被
+// 取消。如果组件没有启动，则返回一个已经取消的上下文
+func (l *DefaultLifecycle) Context() context.Context {
+	return lock.RLockGet(l, func() context.Context {
+		if l.ctx == nil {
+			return canceledContext
+		}
+		return l.ctx
+	})
+}
+
+// newContext 为组件本次运行创建新的上下文，调用时必须持有组件的锁
+func (l *DefaultLifecycle) newContext() {
+	l.ctx, l.cancel = context.WithCancel(context.Background())
+}
+
+// cancelContext 取消组件本次运行的上下文，调用时必须持有组件的锁
+func (l *DefaultLifecycle) cancelContext() {
+	if l.cancel != nil {
+		l.cancel()
+	}
+}
+
+// markClosing 将组件的状态切换为关闭中并取消组件的上下文，调用时必须持有组件的锁
+func (l *DefaultLifecycle) markClosing() {
+	l.ToClosing()
+	l.cancelContext()
+}
+
+// InterrupterContext 创建一个在收到中断信号时被取消的上下文，用于在基于中断信号的代码
+// 中调用基于上下文的代码。返回的 stop 函数必须被调用，调用后如果中断信号已经被上下文
+// 消费，则重新将中断信号放回，因此原有的中断信号处理逻辑不受影响
+func InterrupterContext(parent context.Context, interrupter chan struct{}) (ctx context.Context, stop func()) {
+	ctx, cancel := context.WithCancel(parent)
+	done := make(chan struct{})
+	var interrupted bool
+	go func() {
+		defer close(done)
+		select {
+		case <-interrupter:
+			interrupted = true
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, func() {
+		cancel()
+		<-done
+		if interrupted {
+			utils.ChanTryPush(interrupter, struct{}{})
+		}
+	}
+}
+
+// ContextInterrupter 创建一个在上下文结束时收到中断信号的通道，用于在基于上下文的代码
+// 中调用基于中断信号的代码。返回的 stop 函数必须被调用，用于释放相关资源
+func ContextInterrupter(ctx context.Context) (interrupter chan struct{}, stop func()) {
+	interrupter = make(chan struct{}, 1)
+	stopChan := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		select {
+		case <-ctx.Done():
+			interrupter <- struct{}{}
+		case <-stopChan:
+		}
+	}()
+	return interrupter, func() {
+		close(stopChan)
+		<-done
+	}
+}
+
+// ContextInterruptedRunFunc 将基于上下文的运行函数转换为基于中断信号的运行函数，上下文
+// 继承自 GetContext 获取的组件上下文，并在收到中断信号时被取消
+func ContextInterruptedRunFunc(runFn ContextRunFunc) InterruptedRunFunc {
+	return func(lifecycle Lifecycle, interrupter chan struct{}) error {
+		ctx, stop := InterrupterContext(GetContext(lifecycle), interrupter)
+		defer stop()
+		return runFn(ctx, lifecycle)
+	}
+}
+
+// InterruptedContextRunFunc 将基于中断信号的运行函数转换为基于上下文的运行函数，上下文
+// 结束时运行函数收到中断信号
+func InterruptedContextRunFunc(runFn InterruptedRunFunc) ContextRunFunc {
+	return func(ctx context.Context, lifecycle Lifecycle) error {
+		interrupter, stop := ContextInterrupter(ctx)
+		defer stop()
+		return runFn(lifecycle, interrupter)
+	}
+}
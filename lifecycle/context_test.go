@@ -0,0 +1,52 @@
+package lifecycle
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestContextRun(t *testing.T) {
+	var started context.Context
+	lifecycle := NewWithContextRun(func(ctx context.Context, _ Lifecycle) error {
+		started = ctx
+		return nil
+	}, ContextHoldRun)
+	if lifecycle.Context().Err() == nil {
+		t.Error("context of closed lifecycle should be canceled")
+	}
+	g := NewGroup()
+	g.MustAdd("context", lifecycle)
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if started.Err() != nil || lifecycle.Context() != started {
+		t.Error("context should be alive while running")
+	}
+	if err := CloseWithTimeout(g, time.Second); err != nil {
+		t.Fatal(err)
+	}
+	if started.Err() == nil {
+		t.Error("context should be canceled after closed")
+	}
+}
+
+func TestInterrupterContext(t *testing.T) {
+	interrupter := make(chan struct{}, 1)
+	ctx, stop := InterrupterContext(context.Background(), interrupter)
+	interrupter <- struct{}{}
+	<-ctx.Done()
+	stop()
+	select {
+	case <-interrupter:
+	default:
+		t.Error("interrupt signal should be restored after stop")
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runFn := InterruptedContextRunFunc(InterrupterHoldRun)
+	cancel()
+	if runFn(ctx, nil) != nil {
+		t.Error("unexpected error")
+	}
+}
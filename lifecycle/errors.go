@@ -2,6 +2,8 @@ package lifecycle
 
 import (
 	"fmt"
+	"strings"
+	"time"
 )
 
 type UnknownStateError struct {
@@ -245,3 +247,158 @@ func (e *StateNotClosedError) Error() string {
 		return fmt.Sprintf("%s未关闭", e.Target)
 	}
 }
+
+type DependencyNotFoundError struct {
+	Target     string
+	Dependency string
+}
+
+func NewDependencyNotFoundError(target string, dependency string) *DependencyNotFoundError {
+	return &DependencyNotFoundError{Target: target, Dependency: dependency}
+}
+
+func (e *DependencyNotFoundError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Target == "" {
+		return fmt.Sprintf("生命周期组件依赖的组件(%s)不存在", e.Dependency)
+	} else {
+		return fmt.Sprintf("%s依赖的组件(%s)不存在", e.Target, e.Dependency)
+	}
+}
+
+type DependencyCycleError struct {
+	Target string
+	Cycle  []string
+}
+
+func NewDependencyCycleError(target string, cycle []string) *DependencyCycleError {
+	return &DependencyCycleError{Target: target, Cycle: cycle}
+}
+
+func (e *DependencyCycleError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Target == "" {
+		return fmt.Sprintf("生命周期组件存在循环依赖(%s)", strings.Join(e.Cycle, " -> "))
+	} else {
+		return fmt.Sprintf("%s存在循环依赖(%s)", e.Target, strings.Join(e.Cycle, " -> "))
+	}
+}
+
+// ChildStartError 为子组件启动失败导致父组件启动失败的错误，Err 为子组件启动的错误
+type ChildStartError struct {
+	Target string
+	Child  string
+	Err    error
+}
+
+func NewChildStartError(target string, child string, err error) *ChildStartError {
+	return &ChildStartError{Target: target, Child: child, Err: err}
+}
+
+func (e *ChildStartError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Target == "" {
+		return fmt.Sprintf("生命周期组件的子组件(%s)启动失败: %s", e.Child, e.Err)
+	} else {
+		return fmt.Sprintf("%s的子组件(%s)启动失败: %s", e.Target, e.Child, e.Err)
+	}
+}
+
+func (e *ChildStartError) Unwrap() error {
+	return e.Err
+}
+
+type ShutdownTimeoutError struct {
+	Target string
+	Stuck  []string
+}
+
+func NewShutdownTimeoutError(target string, stuck []string) *ShutdownTimeoutError {
+	return &ShutdownTimeoutError{Target: target, Stuck: stuck}
+}
+
+func (e *ShutdownTimeoutError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	target := e.Target
+	if target == "" {
+		target = "生命周期组件"
+	}
+	if len(e.Stuck) == 0 {
+		return fmt.Sprintf("%s关闭超时", target)
+	} else {
+		return fmt.Sprintf("%s关闭超时，未关闭的子组件(%s)", target, strings.Join(e.Stuck, ","))
+	}
+}
+
+type RestartIntensityError struct {
+	Target      string
+	MaxRestarts int
+	Window      time.Duration
+	Child       string
+	Err         error
+}
+
+func NewRestartIntensityError(target string, maxRestarts int, window time.Duration, child string, err error) *RestartIntensityError {
+	return &RestartIntensityError{Target: target, MaxRestarts: maxRestarts, Window: window, Child: child, Err: err}
+}
+
+func (e *RestartIntensityError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	target := e.Target
+	if target == "" {
+		target = "生命周期组件"
+	}
+	msg := fmt.Sprintf("%s在%s内重启子组件的次数超过%d次，最后退出的子组件(%s)", target, e.Window, e.MaxRestarts, e.Child)
+	if e.Err != nil {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+func (e *RestartIntensityError) Unwrap() error {
+	return e.Err
+}
+
+type PauseNotSupportedError struct {
+	Target string
+}
+
+func NewPauseNotSupportedError(target string) *PauseNotSupportedError {
+	return &PauseNotSupportedError{Target: target}
+}
+
+func (e *PauseNotSupportedError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Target == "" {
+		return "生命周期组件不支持暂停"
+	} else {
+		return fmt.Sprintf("%s不支持暂停", e.Target)
+	}
+}
+
+type FutureTimeoutError struct {
+	Timeout time.Duration
+}
+
+func NewFutureTimeoutError(timeout time.Duration) *FutureTimeoutError {
+	return &FutureTimeoutError{Timeout: timeout}
+}
+
+func (e *FutureTimeoutError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("等待超时(%s)", e.Timeout)
+}
@@ -0,0 +1,208 @@
+package lifecycle
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"sync"
+	"time"
+)
+
+// OnceFuture 为只能完成一次的 Future，可以在多个协程中并发完成，只有第一次完成的值有效，
+// 之后的完成被忽略。完成后所有等待的协程都可以获取到完成的值
+type OnceFuture[V any] struct {
+	value     V
+	done      chan struct{}
+	completed bool
+	callbacks []func(value V)
+	mu        sync.Mutex
+}
+
+func NewOnceFuture[V any]() *OnceFuture[V] {
+	return &OnceFuture[V]{done: make(chan struct{})}
+}
+
+// TryComplete 完成 Future，如果 Future 已经完成，返回false
+func (f *OnceFuture[V]) TryComplete(value V) bool {
+	f.mu.Lock()
+	if f.completed {
+		f.mu.Unlock()
+		return false
+	}
+	f.value, f.completed = value, true
+	callbacks := f.callbacks
+	f.callbacks = nil
+	close(f.done)
+	f.mu.Unlock()
+	for _, callback := range callbacks {
+		callback(value)
+	}
+	return true
+}
+
+func (f *OnceFuture[V]) Complete(value V) {
+	f.TryComplete(value)
+}
+
+// Done 返回一个在 Future 完成后关闭的通道
+func (f *OnceFuture[V]) Done() <-chan struct{} {
+	return f.done
+}
+
+func (f *OnceFuture[V]) Wait() V {
+	<-f.done
+	return f.value
+}
+
+// WaitTimeout 等待 Future 完成，如果超过指定的时间仍未完成，返回false
+func (f *OnceFuture[V]) WaitTimeout(timeout time.Duration) (value V, ok bool) {
+	select {
+	case <-f.done:
+		return f.value, true
+	default:
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-f.done:
+		return f.value, true
+	case <-timer.C:
+		return value, false
+	}
+}
+
+// WaitInterrupted 等待 Future 完成，如果等待期间收到中断信号，返回false
+func (f *OnceFuture[V]) WaitInterrupted(interrupter chan struct{}) (value V, ok bool) {
+	select {
+	case <-f.done:
+		return f.value, true
+	case <-interrupter:
+		return value, false
+	}
+}
+
+// OnComplete 添加 Future 完成时的回调，如果 Future 已经完成，则立即调用回调
+func (f *OnceFuture[V]) OnComplete(callback func(value V)) {
+	f.mu.Lock()
+	if !f.completed {
+		f.callbacks = append(f.callbacks, callback)
+		f.mu.Unlock()
+		return
+	}
+	f.mu.Unlock()
+	callback(f.value)
+}
+
+// onComplete 在 Future 完成后调用回调，如果 Future 不是 OnceFuture，则启动一个协程等待
+// Future 完成。ChanFuture 的值只能被等待一次，不能同时传递给多个组合器
+func onComplete[V any](future WaitableFuture[V], callback func(value V)) {
+	if once, is := future.(*OnceFuture[V]); is {
+		once.OnComplete(callback)
+		return
+	}
+	go func() { callback(future.Wait()) }()
+}
+
+// All 返回一个在所有 Future 完成后完成的 Future，完成的值按照参数的顺序排列
+func All[V any](futures ...WaitableFuture[V]) *OnceFuture[[]V] {
+	result := NewOnceFuture[[]V]()
+	values := make([]V, len(futures))
+	if len(futures) == 0 {
+		result.Complete(values)
+		return result
+	}
+	remain := len(futures)
+	mu := sync.Mutex{}
+	for i, future := range futures {
+		i := i
+		onComplete(future, func(value V) {
+			mu.Lock()
+			values[i] = value
+			remain--
+			finished := remain == 0
+			mu.Unlock()
+			if finished {
+				result.Complete(values)
+			}
+		})
+	}
+	return result
+}
+
+// AllError 返回一个在所有错误 Future 完成后完成的 Future，完成的值为所有非空错误的合并，
+// 可以用于同时等待多个生命周期组件启动或关闭
+func AllError(futures ...WaitableFuture[error]) *OnceFuture[error] {
+	return Map[[]error](All(futures...), func(errs []error) error {
+		return errors.MakeErrors(errs...)
+	})
+}
+
+// First 返回一个在任意一个 Future 完成后完成的 Future，完成的值为第一个完成的 Future 的值
+func First[V any](futures ...WaitableFuture[V]) *OnceFuture[V] {
+	result := NewOnceFuture[V]()
+	for _, future := range futures {
+		onComplete(future, result.Complete)
+	}
+	return result
+}
+
+// Any 返回一个在任意一个错误 Future 以nil完成后以nil完成的 Future，如果所有的 Future
+// 都以错误完成，则以所有错误的合并完成
+func Any(futures ...WaitableFuture[error]) *OnceFuture[error] {
+	result := NewOnceFuture[error]()
+	if len(futures) == 0 {
+		result.Complete(nil)
+		return result
+	}
+	errs := make([]error, len(futures))
+	remain := len(futures)
+	mu := sync.Mutex{}
+	for i, future := range futures {
+		i := i
+		onComplete(future, func(err error) {
+			if err == nil {
+				result.Complete(nil)
+				return
+			}
+			mu.Lock()
+			errs[i] = err
+			remain--
+			finished := remain == 0
+			mu.Unlock()
+			if finished {
+				result.Complete(errors.MakeErrors(errs...))
+			}
+		})
+	}
+	return result
+}
+
+// Map 返回一个在 Future 完成后以 fn 转换后的值完成的 Future
+func Map[V, R any](future WaitableFuture[V], fn func(value V) R) *OnceFuture[R] {
+	result := NewOnceFuture[R]()
+	onComplete(future, func(value V) { result.Complete(fn(value)) })
+	return result
+}
+
+// Then 在 Future 完成后使用完成的值调用 fn 获取下一个 Future，返回的 Future 在下一个
+// Future 完成后完成
+func Then[V, R any](future WaitableFuture[V], fn func(value V) WaitableFuture[R]) *OnceFuture[R] {
+	result := NewOnceFuture[R]()
+	onComplete(future, func(value V) { onComplete(fn(value), result.Complete) })
+	return result
+}
+
+// Timeout 返回一个在 Future 完成或超时后完成的 Future，超时后以 fallback 完成
+func Timeout[V any](future WaitableFuture[V], timeout time.Duration, fallback V) *OnceFuture[V] {
+	result := NewOnceFuture[V]()
+	timer := time.AfterFunc(timeout, func() { result.Complete(fallback) })
+	onComplete(future, func(value V) {
+		timer.Stop()
+		result.Complete(value)
+	})
+	return result
+}
+
+// TimeoutError 返回一个在错误 Future 完成或超时后完成的 Future，超时后以
+// FutureTimeoutError 完成
+func TimeoutError(future WaitableFuture[error], timeout time.Duration) *OnceFuture[error] {
+	return Timeout(future, timeout, error(NewFutureTimeoutError(timeout)))
+}
@@ -20,6 +20,8 @@ type NopFuture[V any] struct{}
 
 func (f NopFuture[V]) Complete(value V) {}
 
+// ChanFuture 使用通道传递完成的值，通道的缓冲区满时 Complete 会阻塞，需要多次完成或
+// 并发完成时使用 OnceFuture
 type ChanFuture[V any] chan V
 
 func (c ChanFuture[V]) Complete(value V) { c <- value }
@@ -64,7 +66,7 @@ func NewCallbackFuture[V any](callback func(value V)) *CallbackFuture[V] {
 	return future
 }
 
-func (f CallbackFuture[V]) Complete(value V) {
+func (f *CallbackFuture[V]) Complete(value V) {
 	if f.once.CompareAndSwap(false, true) {
 		f.value.Store(&value)
 		if callback := f.callback.Load(); callback != nil && f.done.CompareAndSwap(false, true) {
@@ -73,7 +75,7 @@ func (f CallbackFuture[V]) Complete(value V) {
 	}
 }
 
-func (f CallbackFuture[V]) SetCallback(callback func(value V)) {
+func (f *CallbackFuture[V]) SetCallback(callback func(value V)) {
 	f.callback.Store(&callback)
 	if value := f.value.Load(); value != nil && f.done.CompareAndSwap(false, true) {
 		callback(*value)
@@ -0,0 +1,120 @@
+package lifecycle
+
+import (
+	"fmt"
+	"gitee.com/sy_183/common/errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestOnceFuture(t *testing.T) {
+	future := NewOnceFuture[int]()
+	var completed int
+	var mu sync.Mutex
+	var waiter sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		waiter.Add(1)
+		go func(i int) {
+			defer waiter.Done()
+			if future.TryComplete(i) {
+				mu.Lock()
+				completed++
+				mu.Unlock()
+			}
+		}(i)
+	}
+	waiter.Wait()
+	if completed != 1 {
+		t.Errorf("future should be completed exactly once, completed %d times", completed)
+	}
+	value := future.Wait()
+	future.Complete(100)
+	if v, ok := future.WaitTimeout(0); !ok || v != value {
+		t.Errorf("later complete should be ignored, expect %d, got %d", value, v)
+	}
+	var callback int
+	future.OnComplete(func(v int) { callback = v })
+	if callback != value {
+		t.Errorf("callback on completed future should be called immediately")
+	}
+}
+
+func TestFutureCombinators(t *testing.T) {
+	a, b, c := NewOnceFuture[int](), NewOnceFuture[int](), NewWaiterFuture[int]()
+	all := All[int](a, b, c)
+	first := First[int](a, b, c)
+	sum := Map[[]int](all, func(values []int) int { return values[0] + values[1] + values[2] })
+	b.Complete(2)
+	if v, _ := first.WaitTimeout(time.Second); v != 2 {
+		t.Errorf("first expect 2, got %d", v)
+	}
+	a.Complete(1)
+	c.Complete(3)
+	if values := all.Wait(); fmt.Sprint(values) != "[1 2 3]" {
+		t.Errorf("all expect [1 2 3], got %v", values)
+	}
+	if v := sum.Wait(); v != 6 {
+		t.Errorf("map expect 6, got %d", v)
+	}
+
+	then := Then[int, string](sum, func(v int) WaitableFuture[string] {
+		next := NewOnceFuture[string]()
+		time.AfterFunc(time.Millisecond*10, func() { next.Complete(fmt.Sprint("sum=", v)) })
+		return next
+	})
+	if v := then.Wait(); v != "sum=6" {
+		t.Errorf("then expect sum=6, got %s", v)
+	}
+
+	failed := errors.New("failed")
+	e1, e2 := NewOnceFuture[error](), NewOnceFuture[error]()
+	anyErr := Any(e1, e2)
+	e1.Complete(failed)
+	e2.Complete(nil)
+	if err := anyErr.Wait(); err != nil {
+		t.Errorf("any expect nil, got %v", err)
+	}
+	e3, e4 := NewOnceFuture[error](), NewOnceFuture[error]()
+	allErr := AllError(e3, e4)
+	e3.Complete(failed)
+	e4.Complete(nil)
+	if err := allErr.Wait(); err != failed {
+		t.Errorf("all error expect failed, got %v", err)
+	}
+
+	var timeoutErr *FutureTimeoutError
+	if err := TimeoutError(NewOnceFuture[error](), time.Millisecond*10).Wait(); !errors.As(err, &timeoutErr) {
+		t.Errorf("expect timeout error, got %v", err)
+	}
+	if v := Timeout[int](a, time.Second, -1).Wait(); v != 1 {
+		t.Errorf("completed future should not time out, got %d", v)
+	}
+}
+
+func TestFutureLifecycles(t *testing.T) {
+	var started []WaitableFuture[error]
+	var lifecycles []Lifecycle
+	for i := 0; i < 3; i++ {
+		l := NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+			<-interrupter
+			return nil
+		})
+		lifecycles = append(lifecycles, l)
+		started = append(started, l.AddStartedFuture(NewOnceFuture[error]()).(*OnceFuture[error]))
+	}
+	for _, l := range lifecycles {
+		l.Background()
+	}
+	if err := TimeoutError(AllError(started...), time.Second).Wait(); err != nil {
+		t.Fatalf("all lifecycles should start, got %v", err)
+	}
+	var closed []WaitableFuture[error]
+	for _, l := range lifecycles {
+		closed = append(closed, l.AddClosedFuture(NewOnceFuture[error]()).(*OnceFuture[error]))
+		l.Close(nil)
+	}
+	if err := TimeoutError(AllError(closed...), time.Second).Wait(); err != nil {
+		t.Fatalf("all lifecycles should close, got %v", err)
+	}
+}
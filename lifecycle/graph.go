@@ -0,0 +1,521 @@
+package lifecycle
+
+import (
+	"gitee.com/sy_183/common/assert"
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/lock"
+	"sync"
+	"sync/atomic"
+)
+
+const GraphFieldName = "$graph"
+
+type GraphLifecycleHolder struct {
+	Lifecycle
+	name  string
+	graph *Graph
+
+	// dependencyNames 为添加组件时指定的依赖的组件名称，dependencies 和 dependents 在
+	// 生命周期图启动时根据名称解析
+	dependencyNames []string
+	dependencies    []*GraphLifecycleHolder
+	dependents      []*GraphLifecycleHolder
+
+	closeAllOnStartError       atomic.Bool
+	closeAllOnExit             atomic.Bool
+	closeAllOnExitError        atomic.Bool
+	closeDependentsOnExit      atomic.Bool
+	closeDependentsOnExitError atomic.Bool
+}
+
+func (h *GraphLifecycleHolder) Name() string {
+	return h.name
+}
+
+func (h *GraphLifecycleHolder) Graph() *Graph {
+	return h.graph
+}
+
+func (h *GraphLifecycleHolder) Dependencies() []string {
+	return append([]string(nil), h.dependencyNames...)
+}
+
+func (h *GraphLifecycleHolder) SetCloseAllOnStartError(enable bool) *GraphLifecycleHolder {
+	h.closeAllOnStartError.Store(enable)
+	return h
+}
+
+func (h *GraphLifecycleHolder) SetCloseAllOnExit(enable bool) *GraphLifecycleHolder {
+	h.closeAllOnExit.Store(enable)
+	return h
+}
+
+func (h *GraphLifecycleHolder) SetCloseAllOnExitError(enable bool) *GraphLifecycleHolder {
+	h.closeAllOnExitError.Store(enable)
+	return h
+}
+
+func (h *GraphLifecycleHolder) SetCloseDependentsOnExit(enable bool) *GraphLifecycleHolder {
+	h.closeDependentsOnExit.Store(enable)
+	return h
+}
+
+func (h *GraphLifecycleHolder) SetCloseDependentsOnExitError(enable bool) *GraphLifecycleHolder {
+	h.closeDependentsOnExitError.Store(enable)
+	return h
+}
+
+type (
+	graphLifecycleContext = childLifecycleContext[*GraphLifecycleHolder]
+	graphLifecycleChannel = childLifecycleChannel[*GraphLifecycleHolder]
+)
+
+// graphChildState 为生命周期图在一次运行过程中记录的子组件状态，只在生命周期图的运行协程中访问
+type graphChildState int
+
+const (
+	// 等待依赖的组件启动完成
+	graphChildWaiting = graphChildState(iota)
+	graphChildStarting
+	graphChildRunning
+	// 由于依赖的组件启动失败或退出，组件不会被启动
+	graphChildSkipped
+	graphChildStartFailed
+	// 组件由生命周期图主动关闭
+	graphChildClosing
+	graphChildExited
+)
+
+// Graph 按照子组件之间的依赖关系启动和关闭子组件，组件只有在其依赖的组件全部启动
+// 完成后才会启动，没有依赖关系的组件会并行启动，关闭时按照相反的顺序执行
+type Graph struct {
+	Lifecycle
+	lifecycle *DefaultLifecycle
+
+	children     map[string]*GraphLifecycleHolder
+	order        []*GraphLifecycleHolder
+	childrenLock sync.Mutex
+
+	states map[*GraphLifecycleHolder]graphChildState
+	// startErr 为启动过程中导致生命周期图关闭的子组件启动错误
+	startErr error
+
+	runningChannel *graphLifecycleChannel
+	closedChannel  *graphLifecycleChannel
+}
+
+func NewGraph() *Graph {
+	g := &Graph{
+		children:       make(map[string]*GraphLifecycleHolder),
+		runningChannel: newChildLifecycleChannel[*GraphLifecycleHolder](),
+		closedChannel:  newChildLifecycleChannel[*GraphLifecycleHolder](),
+	}
+	g.lifecycle = NewWithInterruptedStart(g.start)
+	g.lifecycle.boundedClose = true
+	g.lifecycle.pauser = FuncPauser(g.pause, g.resume)
+	g.Lifecycle = g.lifecycle
+	return g
+}
+
+func (g *Graph) delegate() Lifecycle {
+	return g.lifecycle
+}
+
+// Add 添加子组件及其依赖的组件名称，依赖的组件可以在此组件之后添加，生命周期图启动时
+// 解析依赖关系，依赖的组件不存在时启动失败。如果添加组件时已经可以确定形成了循环依赖，
+// 则返回 DependencyCycleError
+func (g *Graph) Add(name string, lifecycle Lifecycle, dependencies ...string) (*GraphLifecycleHolder, error) {
+	return lock.RLockGetDouble(g.lifecycle, func() (*GraphLifecycleHolder, error) {
+		if !g.lifecycle.Closed() {
+			return nil, NewStateNotClosedError("")
+		}
+		return lock.LockGetDouble(&g.childrenLock, func() (*GraphLifecycleHolder, error) {
+			if _, has := g.children[name]; has {
+				return nil, errors.New("生命周期组件已经存在")
+			}
+			if cycle := g.findCycle(name, dependencies); cycle != nil {
+				return nil, NewDependencyCycleError("", cycle)
+			}
+			child := &GraphLifecycleHolder{
+				Lifecycle:       lifecycle,
+				name:            name,
+				graph:           g,
+				dependencyNames: append([]string(nil), dependencies...),
+			}
+			child.SetCloseAllOnStartError(true)
+			child.SetCloseAllOnExit(true)
+			child.SetCloseAllOnExitError(true)
+			child.SetCloseDependentsOnExit(true)
+			child.SetCloseDependentsOnExitError(true)
+			child.SetField(GraphFieldName, g)
+			child.SetDefaultField(NameFieldName, name)
+			g.children[name] = child
+			g.order = append(g.order, child)
+			return child, nil
+		})
+	})
+}
+
+// findCycle 查找添加名称为 name 的组件后形成的循环依赖，只能沿着已经添加的组件查找，返
+// 回循环依赖经过的组件名称，没有找到时返回nil。调用时必须持有 childrenLock
+func (g *Graph) findCycle(name string, dependencies []string) []string {
+	visited := make(map[string]bool)
+	var find func(path []string, dependencies []string) []string
+	find = func(path []string, dependencies []string) []string {
+		for _, dependency := range dependencies {
+			if dependency == name {
+				return append(path, name)
+			}
+			if visited[dependency] {
+				continue
+			}
+			visited[dependency] = true
+			if child := g.children[dependency]; child != nil {
+				if cycle := find(append(path, dependency), child.dependencyNames); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return find([]string{name}, dependencies)
+}
+
+// resolve 根据依赖的组件名称解析子组件之间的依赖关系，并将子组件按照拓扑顺序排列
+func (g *Graph) resolve() error {
+	return lock.LockGet(&g.childrenLock, func() error {
+		for _, child := range g.order {
+			child.dependencies, child.dependents = nil, nil
+		}
+		for _, child := range g.order {
+			for _, dependency := range child.dependencyNames {
+				depend := g.children[dependency]
+				if depend == nil {
+					return NewDependencyNotFoundError(child.name, dependency)
+				}
+				child.dependencies = append(child.dependencies, depend)
+				depend.dependents = append(depend.dependents, child)
+			}
+		}
+		// 按照添加的顺序进行深度优先遍历，使没有依赖关系的组件保持添加的顺序
+		const (
+			unvisited = iota
+			visiting
+			visited
+		)
+		marks := make(map[*GraphLifecycleHolder]int)
+		order := make([]*GraphLifecycleHolder, 0, len(g.order))
+		var visit func(path []string, child *GraphLifecycleHolder) error
+		visit = func(path []string, child *GraphLifecycleHolder) error {
+			switch marks[child] {
+			case visiting:
+				for i, name := range path {
+					if name == child.name {
+						path = path[i:]
+						break
+					}
+				}
+				return NewDependencyCycleError("", append(path, child.name))
+			case visited:
+				return nil
+			}
+			marks[child] = visiting
+			for _, depend := range child.dependencies {
+				if err := visit(append(path, child.name), depend); err != nil {
+					return err
+				}
+			}
+			marks[child] = visited
+			order = append(order, child)
+			return nil
+		}
+		for _, child := range g.order {
+			if err := visit(nil, child); err != nil {
+				return err
+			}
+		}
+		g.order = order
+		return nil
+	})
+}
+
+func (g *Graph) MustAdd(name string, lifecycle Lifecycle, dependencies ...string) *GraphLifecycleHolder {
+	return assert.Must(g.Add(name, lifecycle, dependencies...))
+}
+
+func (g *Graph) Get(name string) *GraphLifecycleHolder {
+	return lock.LockGet(&g.childrenLock, func() *GraphLifecycleHolder { return g.children[name] })
+}
+
+// getChildren 返回子组件，生命周期图启动后子组件按照拓扑顺序排列，启动之前按照添加的
+// 顺序排列
+func (g *Graph) getChildren() []*GraphLifecycleHolder {
+	return lock.LockGet(&g.childrenLock, func() []*GraphLifecycleHolder {
+		return append([]*GraphLifecycleHolder(nil), g.order...)
+	})
+}
+
+func (g *Graph) launchChild(child *GraphLifecycleHolder) {
+	g.states[child] = graphChildStarting
+	child.AddStartedFuture(graphLifecycleContext{
+		Lifecycle: child,
+		channel:   g.runningChannel,
+	})
+	child.Background()
+}
+
+// launchReady 启动所有依赖已经全部启动完成的组件，如果组件依赖的组件不会再启动，则
+// 跳过此组件
+func (g *Graph) launchReady() {
+	for _, child := range g.getChildren() {
+		if g.states[child] != graphChildWaiting {
+			continue
+		}
+		ready, skip := true, false
+		for _, depend := range child.dependencies {
+			switch g.states[depend] {
+			case graphChildRunning:
+			case graphChildWaiting, graphChildStarting:
+				ready = false
+			default:
+				skip = true
+			}
+		}
+		switch {
+		case skip:
+			g.states[child] = graphChildSkipped
+		case ready:
+			g.launchChild(child)
+		}
+	}
+}
+
+func (g *Graph) settled() bool {
+	for _, state := range g.states {
+		if state == graphChildWaiting || state == graphChildStarting {
+			return false
+		}
+	}
+	return true
+}
+
+// dependentsOf 返回组件所有正在启动或运行的直接和间接依赖者
+func (g *Graph) dependentsOf(child *GraphLifecycleHolder) map[*GraphLifecycleHolder]struct{} {
+	dependents := make(map[*GraphLifecycleHolder]struct{})
+	queue := append([]*GraphLifecycleHolder(nil), child.dependents...)
+	for len(queue) > 0 {
+		dependent := queue[0]
+		queue = queue[1:]
+		if _, has := dependents[dependent]; has {
+			continue
+		}
+		if state := g.states[dependent]; state == graphChildStarting || state == graphChildRunning {
+			dependents[dependent] = struct{}{}
+		}
+		queue = append(queue, dependent.dependents...)
+	}
+	return dependents
+}
+
+// shutdownChildren 按照拓扑顺序的逆序关闭指定的子组件，如果targets为nil，则关闭所
+// 有正在启动或运行的子组件。组件只有在依赖它的组件全部关闭后才会被关闭，互相没有依赖
+// 关系的组件会并行关闭。如果关闭上下文结束时仍有组件未关闭，则不再等待依赖它的组件，
+// 直接中断剩余的所有组件
+func (g *Graph) shutdownChildren(targets map[*GraphLifecycleHolder]struct{}) (stuck []string) {
+	ctx := g.lifecycle.closeContext()
+	remaining := make(map[*GraphLifecycleHolder]struct{})
+	for child, state := range g.states {
+		if state != graphChildStarting && state != graphChildRunning {
+			continue
+		}
+		if targets != nil {
+			if _, has := targets[child]; !has {
+				continue
+			}
+		}
+		remaining[child] = struct{}{}
+	}
+	for len(remaining) > 0 {
+		if ctx.Err() != nil {
+			var names []string
+			var children []Lifecycle
+			for child := range remaining {
+				g.states[child] = graphChildClosing
+				names = append(names, child.name)
+				children = append(children, child)
+			}
+			return append(stuck, interruptChildren(ctx, names, children)...)
+		}
+		var wave []*GraphLifecycleHolder
+		for child := range remaining {
+			blocked := false
+			for _, dependent := range child.dependents {
+				if _, has := remaining[dependent]; has {
+					blocked = true
+					break
+				}
+			}
+			if !blocked {
+				wave = append(wave, child)
+			}
+		}
+		var waiter sync.WaitGroup
+		var stuckLock sync.Mutex
+		for _, child := range wave {
+			g.states[child] = graphChildClosing
+			delete(remaining, child)
+			waiter.Add(1)
+			go func(child *GraphLifecycleHolder) {
+				defer waiter.Done()
+				if names := stuckChildren(child.name, ShutdownContext(child, ctx)); len(names) > 0 {
+					lock.LockDo(&stuckLock, func() { stuck = append(stuck, names...) })
+				}
+			}(child)
+		}
+		waiter.Wait()
+	}
+	return
+}
+
+func (g *Graph) closeAll() {
+	lock.LockDo(g.lifecycle, func() { g.lifecycle.markClosing() })
+	g.lifecycle.notifyEvents()
+	g.shutdownChildren(nil)
+}
+
+func (g *Graph) handleRunningSignal() (closeAll bool) {
+	for _, ctx := range g.runningChannel.Pop() {
+		child := ctx.Lifecycle
+		if g.states[child] != graphChildStarting {
+			continue
+		}
+		if ctx.err != nil {
+			g.states[child] = graphChildStartFailed
+			if child.closeAllOnStartError.Load() {
+				closeAll = true
+				if g.startErr == nil {
+					g.startErr = NewChildStartError("生命周期图", child.name, ctx.err)
+				}
+			}
+		} else {
+			g.states[child] = graphChildRunning
+			child.AddClosedFuture(graphLifecycleContext{
+				Lifecycle: child,
+				channel:   g.closedChannel,
+			})
+		}
+	}
+	if closeAll {
+		g.closeAll()
+		return true
+	}
+	g.launchReady()
+	return false
+}
+
+func (g *Graph) handleClosedSignal() (closeAll bool) {
+	closeDependents := make(map[*GraphLifecycleHolder]struct{})
+	for _, ctx := range g.closedChannel.Pop() {
+		child := ctx.Lifecycle
+		state := g.states[child]
+		g.states[child] = graphChildExited
+		if state != graphChildRunning {
+			// 由生命周期图主动关闭的组件退出时不需要处理
+			continue
+		}
+		var closeDependent bool
+		if ctx.err != nil {
+			if child.closeAllOnExit.Load() || child.closeAllOnExitError.Load() {
+				closeAll = true
+			}
+			closeDependent = child.closeDependentsOnExit.Load() || child.closeDependentsOnExitError.Load()
+		} else {
+			if child.closeAllOnExit.Load() {
+				closeAll = true
+			}
+			closeDependent = child.closeDependentsOnExit.Load()
+		}
+		if closeDependent {
+			for dependent := range g.dependentsOf(child) {
+				closeDependents[dependent] = struct{}{}
+			}
+		}
+	}
+	if closeAll {
+		g.closeAll()
+		return true
+	}
+	if len(closeDependents) > 0 {
+		g.shutdownChildren(closeDependents)
+	}
+	g.launchReady()
+	return false
+}
+
+func (g *Graph) start(_ Lifecycle, interrupter chan struct{}) (runFn InterruptedRunFunc, err error) {
+	defer func() {
+		if err != nil {
+			g.reset()
+		}
+	}()
+	if err := g.resolve(); err != nil {
+		return nil, err
+	}
+	g.states = make(map[*GraphLifecycleHolder]graphChildState)
+	g.startErr = nil
+	for _, child := range g.getChildren() {
+		g.states[child] = graphChildWaiting
+	}
+	g.launchReady()
+
+	for !g.settled() {
+		select {
+		case <-g.runningChannel.Signal():
+			if g.handleRunningSignal() {
+				// 子组件启动失败导致生命周期图关闭时，返回子组件启动的错误
+				if g.startErr != nil {
+					return nil, g.startErr
+				}
+				return nil, NewInterruptedError("生命周期图", "启动")
+			}
+		case <-g.closedChannel.Signal():
+			if g.handleClosedSignal() {
+				return nil, NewInterruptedError("生命周期图", "启动")
+			}
+		case <-interrupter:
+			if stuck := g.shutdownChildren(nil); len(stuck) > 0 {
+				return nil, NewShutdownTimeoutError("生命周期图", stuck)
+			}
+			return nil, NewInterruptedError("生命周期图", "启动")
+		}
+	}
+	return g.run, nil
+}
+
+func (g *Graph) run(_ Lifecycle, interrupter chan struct{}) error {
+	defer g.reset()
+	for {
+		select {
+		case <-g.runningChannel.Signal():
+			if g.handleRunningSignal() {
+				return nil
+			}
+		case <-g.closedChannel.Signal():
+			if g.handleClosedSignal() {
+				return nil
+			}
+		case <-interrupter:
+			if stuck := g.shutdownChildren(nil); len(stuck) > 0 {
+				return NewShutdownTimeoutError("生命周期图", stuck)
+			}
+			return nil
+		}
+	}
+}
+
+func (g *Graph) reset() {
+	g.states = nil
+	g.startErr = nil
+	g.runningChannel = newChildLifecycleChannel[*GraphLifecycleHolder]()
+	g.closedChannel = newChildLifecycleChannel[*GraphLifecycleHolder]()
+}
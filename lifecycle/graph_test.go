@@ -0,0 +1,149 @@
+package lifecycle
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type graphTestRecorder struct {
+	events []string
+	mu     sync.Mutex
+}
+
+func (r *graphTestRecorder) record(event string) {
+	r.mu.Lock()
+	r.events = append(r.events, event)
+	r.mu.Unlock()
+}
+
+func (r *graphTestRecorder) index(event string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, e := range r.events {
+		if e == event {
+			return i
+		}
+	}
+	return -1
+}
+
+func (r *graphTestRecorder) newLifecycle(name string) Lifecycle {
+	return NewWithInterruptedRun(func(Lifecycle, chan struct{}) error {
+		time.Sleep(time.Millisecond * 10)
+		r.record("start " + name)
+		return nil
+	}, func(_ Lifecycle, interrupter chan struct{}) error {
+		<-interrupter
+		r.record("close " + name)
+		return nil
+	})
+}
+
+func TestGraph(t *testing.T) {
+	r := new(graphTestRecorder)
+	g := NewGraph()
+	g.MustAdd("db", r.newLifecycle("db"))
+	g.MustAdd("cache", r.newLifecycle("cache"), "db")
+	g.MustAdd("worker", r.newLifecycle("worker"), "db")
+	g.MustAdd("api", r.newLifecycle("api"), "cache")
+
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	before := func(first, second string) {
+		if i, j := r.index(first), r.index(second); i < 0 || j < 0 || i > j {
+			t.Errorf("expect %q before %q, events: %v", first, second, r.events)
+		}
+	}
+	before("start db", "start cache")
+	before("start db", "start worker")
+	before("start cache", "start api")
+	before("close api", "close cache")
+	before("close cache", "close db")
+	before("close worker", "close db")
+}
+
+func TestGraphAddError(t *testing.T) {
+	g := NewGraph()
+	g.MustAdd("db", New())
+	if _, err := g.Add("cache", New(), "cache"); err == nil {
+		t.Error("expect dependency cycle error")
+	} else if _, is := err.(*DependencyCycleError); !is {
+		t.Errorf("unexpected error type %T", err)
+	}
+	g.MustAdd("api", New(), "cache")
+	if _, err := g.Add("cache", New(), "api"); err == nil {
+		t.Error("expect dependency cycle error")
+	} else if cycleErr, is := err.(*DependencyCycleError); !is || strings.Join(cycleErr.Cycle, ",") != "cache,api,cache" {
+		t.Errorf("unexpected error %v", err)
+	}
+	if _, err := g.Add("db", New()); err == nil {
+		t.Error("expect lifecycle exist error")
+	}
+	// 依赖的组件直到启动时仍不存在
+	if err := g.Start(); err == nil {
+		t.Error("expect dependency not found error")
+	} else if notFound, is := err.(*DependencyNotFoundError); !is || notFound.Dependency != "cache" {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestGraphForwardDependency(t *testing.T) {
+	r := new(graphTestRecorder)
+	g := NewGraph()
+	g.MustAdd("api", r.newLifecycle("api"), "cache")
+	g.MustAdd("cache", r.newLifecycle("cache"), "db")
+	g.MustAdd("db", r.newLifecycle("db"))
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	for _, pair := range [][2]string{{"start db", "start cache"}, {"start cache", "start api"}, {"close api", "close cache"}, {"close cache", "close db"}} {
+		if i, j := r.index(pair[0]), r.index(pair[1]); i < 0 || j < 0 || i > j {
+			t.Errorf("expect %q before %q, events: %v", pair[0], pair[1], r.events)
+		}
+	}
+}
+
+func TestGraphParallelStart(t *testing.T) {
+	newSlow := func() Lifecycle {
+		return NewWithInterruptedRun(func(Lifecycle, chan struct{}) error {
+			time.Sleep(time.Millisecond * 50)
+			return nil
+		}, InterrupterHoldRun)
+	}
+	g := NewGraph()
+	g.MustAdd("root", NewWithInterruptedRun(nil, InterrupterHoldRun))
+	g.MustAdd("a", newSlow(), "root")
+	g.MustAdd("b", newSlow(), "root")
+	g.MustAdd("c", newSlow(), "root")
+	begin := time.Now()
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+	if elapsed := time.Since(begin); elapsed > time.Millisecond*120 {
+		t.Errorf("independent branches should start in parallel, elapsed %s", elapsed)
+	}
+}
+
+func TestGraphStartError(t *testing.T) {
+	failed := errors.New("failed")
+	g := NewGraph()
+	g.MustAdd("db", NewWithInterruptedRun(func(Lifecycle, chan struct{}) error { return failed }, nil))
+	g.MustAdd("api", New(), "db")
+	err := g.Start()
+	var startErr *ChildStartError
+	if !errors.As(err, &startErr) || startErr.Child != "db" || !errors.Is(err, failed) {
+		t.Errorf("expect start error of child db, got %v", err)
+	}
+}
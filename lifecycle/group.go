@@ -69,44 +69,66 @@ func NewGroup() *Group {
 		closedChannel:  newChildLifecycleChannel[*GroupLifecycleHolder](),
 	}
 	g.lifecycle = NewWithInterruptedStart(g.start)
+	g.lifecycle.boundedClose = true
+	g.lifecycle.pauser = FuncPauser(g.pause, g.resume)
 	g.Lifecycle = g.lifecycle
 	return g
 }
 
+func (g *Group) delegate() Lifecycle {
+	return g.lifecycle
+}
+
+func (g *Group) newChild(name string, lifecycle Lifecycle) *GroupLifecycleHolder {
+	child := &GroupLifecycleHolder{
+		Lifecycle: lifecycle,
+		name:      name,
+		group:     g,
+	}
+	child.SetCloseAllOnStartError(true)
+	child.SetCloseAllOnExit(true)
+	child.SetCloseAllOnExitError(true)
+	child.SetField(GroupFieldName, g)
+	child.SetDefaultField(NameFieldName, name)
+	return child
+}
+
+// launchChild 启动运行中的生命周期组中新添加的子组件，调用时必须持有生命周期组的读锁
+func (g *Group) launchChild(child *GroupLifecycleHolder, runningChannel *groupLifecycleChannel) {
+	if runningChannel != nil && !g.lifecycle.Closing() {
+		child.AddStartedFuture(groupLifecycleContext{
+			Lifecycle: child,
+			channel:   runningChannel,
+		})
+		child.Background()
+	}
+}
+
+// Add 添加子组件，如果生命周期组正在启动或运行，则立即启动子组件，子组件启动和退出时
+// 按照子组件的策略处理
 func (g *Group) Add(name string, lifecycle Lifecycle) (*GroupLifecycleHolder, error) {
 	return lock.RLockGetDouble(g.lifecycle, func() (*GroupLifecycleHolder, error) {
-		var loaded bool
+		var runningChannel *groupLifecycleChannel
 		child, err := lock.LockGetDouble(&g.childrenLock, func() (*GroupLifecycleHolder, error) {
 			if _, has := g.children[name]; has {
 				return nil, errors.New("生命周期组件已经存在")
 			}
-			loaded = g.loaded
-			child := &GroupLifecycleHolder{
-				Lifecycle: lifecycle,
-				name:      name,
-				group:     g,
+			if g.loaded {
+				runningChannel = g.runningChannel
 			}
-			child.SetCloseAllOnStartError(true)
-			child.SetCloseAllOnExit(true)
-			child.SetCloseAllOnExitError(true)
-			child.SetField(GroupFieldName, g)
+			child := g.newChild(name, lifecycle)
 			g.children[name] = child
 			return child, nil
 		})
 		if err != nil {
 			return nil, err
 		}
-		if loaded && !g.lifecycle.Closing() {
-			child.AddStartedFuture(groupLifecycleContext{
-				Lifecycle: child,
-				channel:   g.runningChannel,
-			})
-			child.Background()
-		}
+		g.launchChild(child, runningChannel)
 		return child, nil
 	})
 }
 
+// Remove 移除子组件，如果生命周期组没有关闭，则关闭子组件，但不等待子组件关闭完成
 func (g *Group) Remove(name string) *GroupLifecycleHolder {
 	return lock.RLockGet(g.lifecycle, func() *GroupLifecycleHolder {
 		child := lock.LockGet(&g.childrenLock, func() *GroupLifecycleHolder {
@@ -129,6 +151,66 @@ func (g *Group) Remove(name string) *GroupLifecycleHolder {
 	})
 }
 
+// detachChild 标记子组件为已移除并等待子组件关闭。子组件在关闭期间仍然占用名称，生命
+// 周期组关闭时也会等待此子组件关闭
+func (g *Group) detachChild(name string) *GroupLifecycleHolder {
+	child := lock.LockGet(&g.childrenLock, func() *GroupLifecycleHolder {
+		child := g.children[name]
+		if child == nil || !child.removed.CompareAndSwap(false, true) {
+			return nil
+		}
+		return child
+	})
+	if child != nil {
+		child.Shutdown()
+	}
+	return child
+}
+
+// RemoveAndWait 移除子组件，并等待子组件关闭完成后再将其从生命周期组中分离
+func (g *Group) RemoveAndWait(name string) *GroupLifecycleHolder {
+	child := g.detachChild(name)
+	if child == nil {
+		return nil
+	}
+	lock.LockDo(&g.childrenLock, func() {
+		if g.children[name] == child {
+			delete(g.children, name)
+		}
+	})
+	child.RemoveField(GroupFieldName)
+	return child
+}
+
+// Replace 使用新的组件替换指定名称的子组件，原有的子组件关闭完成后新的组件才会被添加，
+// 如果生命周期组正在启动或运行，则立即启动新的组件。如果指定名称的子组件不存在，则与
+// Add 相同
+func (g *Group) Replace(name string, lifecycle Lifecycle) (*GroupLifecycleHolder, error) {
+	old := g.detachChild(name)
+	return lock.RLockGetDouble(g.lifecycle, func() (*GroupLifecycleHolder, error) {
+		var runningChannel *groupLifecycleChannel
+		child, err := lock.LockGetDouble(&g.childrenLock, func() (*GroupLifecycleHolder, error) {
+			if exist, has := g.children[name]; has && exist != old {
+				return nil, errors.New("生命周期组件已经存在")
+			}
+			if g.loaded {
+				runningChannel = g.runningChannel
+			}
+			child := g.newChild(name, lifecycle)
+			g.children[name] = child
+			return child, nil
+		})
+		if old != nil {
+			old.RemoveField(GroupFieldName)
+		}
+		if err != nil {
+			return nil, err
+		}
+		g.launchChild(child, runningChannel)
+		return child, nil
+	})
+}
+
 func (g *Group) MustAdd(name string, lifecycle Lifecycle) *GroupLifecycleHolder {
 	return assert.Must(g.Add(name, lifecycle))
 }
@@ -145,7 +227,7 @@ func (g *Group) getChildren(setLoaded bool) []*GroupLifecycleHolder {
 	})
 }
 
-func (g *Group) shutdownChildren(exclude map[*GroupLifecycleHolder]struct{}) {
+func (g *Group) shutdownChildren(exclude map[*GroupLifecycleHolder]struct{}) (stuck []string) {
 	// 此函数必需在状态为Closing的情况下执行
 	excluded := func(child *GroupLifecycleHolder) bool {
 		if exclude != nil {
@@ -154,18 +236,24 @@ func (g *Group) shutdownChildren(exclude map[*GroupLifecycleHolder]struct{}) {
 		}
 		return false
 	}
+	ctx := g.lifecycle.closeContext()
 	children := g.getChildren(false)
-	futures := make([]ChanFuture[error], 0)
+	var waiter sync.WaitGroup
+	var stuckLock sync.Mutex
 	for _, child := range children {
-		if !excluded(child) && !child.removed.Load() {
-			future := make(ChanFuture[error], 1)
-			child.Close(future)
-			futures = append(futures, future)
+		// 正在被移除的子组件仍然在生命周期组中，同样需要等待其关闭
+		if !excluded(child) {
+			waiter.Add(1)
+			go func(child *GroupLifecycleHolder) {
+				defer waiter.Done()
+				if names := stuckChildren(child.name, ShutdownContext(child, ctx)); len(names) > 0 {
+					lock.LockDo(&stuckLock, func() { stuck = append(stuck, names...) })
+				}
+			}(child)
 		}
 	}
-	for _, future := range futures {
-		<-future
-	}
+	waiter.Wait()
+	return
 }
 
 func (g *Group) handleRunningSignal(handleContext func(ctx groupLifecycleContext)) (closeAll bool) {
@@ -195,7 +283,8 @@ func (g *Group) handleRunningSignal(handleContext func(ctx groupLifecycleContext
 		}
 	}
 	if closeAll {
-		lock.LockDo(g.lifecycle, func() { g.lifecycle.ToClosing() })
+		lock.LockDo(g.lifecycle, func() { g.lifecycle.markClosing() })
+		g.lifecycle.notifyEvents()
 		g.shutdownChildren(closedSet)
 		return true
 	}
@@ -228,7 +317,8 @@ func (g *Group) handleClosedSignal(handleContext func(ctx groupLifecycleContext)
 		closedSet[child] = struct{}{}
 	}
 	if closeAll {
-		lock.LockDo(g.lifecycle, func() { g.lifecycle.ToClosing() })
+		lock.LockDo(g.lifecycle, func() { g.lifecycle.markClosing() })
+		g.lifecycle.notifyEvents()
 		g.shutdownChildren(closedSet)
 		return true
 	}
@@ -279,7 +369,9 @@ func (g *Group) start(_ Lifecycle, interrupter chan struct{}) (runFn Interrupted
 				return nil, NewInterruptedError("生命周期组", "启动")
 			}
 		case <-interrupter:
-			g.shutdownChildren(nil)
+			if stuck := g.shutdownChildren(nil); len(stuck) > 0 {
+				return nil, NewShutdownTimeoutError("生命周期组", stuck)
+			}
 			return nil, NewInterruptedError("生命周期组", "启动")
 		}
 	}
@@ -298,14 +390,18 @@ func (g *Group) run(_ Lifecycle, interrupter chan struct{}) error {
 				return nil
 			}
 		case <-interrupter:
-			g.shutdownChildren(nil)
+			if stuck := g.shutdownChildren(nil); len(stuck) > 0 {
+				return NewShutdownTimeoutError("生命周期组", stuck)
+			}
 			return nil
 		}
 	}
 }
 
 func (g *Group) reset() {
-	lock.LockDo(&g.childrenLock, func() { g.loaded = false })
-	g.runningChannel = newChildLifecycleChannel[*GroupLifecycleHolder]()
-	g.closedChannel = newChildLifecycleChannel[*GroupLifecycleHolder]()
+	lock.LockDo(&g.childrenLock, func() {
+		g.loaded = false
+		g.runningChannel = newChildLifecycleChannel[*GroupLifecycleHolder]()
+		g.closedChannel = newChildLifecycleChannel[*GroupLifecycleHolder]()
+	})
 }
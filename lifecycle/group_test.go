@@ -2,6 +2,7 @@ package lifecycle
 
 import (
 	"math/rand"
+	"strconv"
 	"testing"
 	"time"
 )
@@ -52,3 +53,58 @@ func TestGroup(t *testing.T) {
 		MustAdd("test3", NewWithInterruptedStart(starter).OnStarting(onStarting).OnStarted(onStarted).OnClose(onClose).OnClosed(onClosed).SetField("name", "test3")).Group()
 	g.Run()
 }
+
+func TestGroupDynamic(t *testing.T) {
+	g := NewGroup()
+	g.MustAdd("static", NewWithInterruptedRun(nil, InterrupterHoldRun))
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	added := g.MustAdd("camera1", NewWithInterruptedRun(nil, InterrupterHoldRun))
+	if err := <-added.StartedWaiter(); err != nil || !GetState(added).Running() {
+		t.Fatalf("hot added child should be running, state %s, error %v", GetState(added), err)
+	}
+
+	replaced, err := g.Replace("camera1", NewWithInterruptedRun(nil, InterrupterHoldRun))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !GetState(added).Closed() {
+		t.Error("replaced child should be closed")
+	}
+	if err := <-replaced.StartedWaiter(); err != nil {
+		t.Fatal(err)
+	}
+
+	if removed := g.RemoveAndWait("camera1"); removed != replaced || !GetState(removed).Closed() {
+		t.Error("removed child should be closed")
+	}
+	if !GetState(g).Running() {
+		t.Error("group should keep running after removing a child")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; ; i++ {
+			if _, err := g.Add("camera"+strconv.Itoa(i), NewWithInterruptedRun(nil, InterrupterHoldRun)); err != nil {
+				t.Error(err)
+				return
+			}
+			if GetState(g).Closed() {
+				return
+			}
+		}
+	}()
+	time.Sleep(time.Millisecond * 10)
+	if err := g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+	for _, child := range g.TreeChildren() {
+		if !GetState(child.Lifecycle).Closed() {
+			t.Errorf("child %s should be closed after group closed", child.Name)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+package lifecycle
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/lock"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const HealthProberFieldName = "$healthProber"
+
+const (
+	DefaultHealthCheckInterval         = time.Second * 10
+	DefaultHealthCheckTimeout          = time.Second * 3
+	DefaultHealthCheckFailureThreshold = 3
+	DefaultHealthCheckSuccessThreshold = 1
+)
+
+// HealthProber 包装一个生命周期组件，在组件运行期间定期检查组件的健康状态。如果组件
+// 实现了 HealthChecker，则使用组件的检查方法，否则使用 GetHealth 获取组件的健康状态。
+// 连续检查失败的次数达到阈值后组件被认为是不健康的，连续检查成功的次数达到阈值后组件
+// 恢复为健康状态
+type HealthProber[LIFECYCLE Lifecycle] struct {
+	Lifecycle
+	lifecycle LIFECYCLE
+
+	interval         atomic.Int64
+	timeout          atomic.Int64
+	failureThreshold atomic.Int64
+	successThreshold atomic.Int64
+
+	health    atomic.Pointer[Health]
+	failures  int64
+	successes int64
+	// checking 为尚未返回的检查的结果通道，只在检查协程中使用
+	checking chan healthCheckResult
+
+	onHealthChanged     []OnHealthChangedFunc
+	onHealthChangedLock sync.Mutex
+}
+
+func NewHealthProber[LIFECYCLE Lifecycle](lifecycle LIFECYCLE) *HealthProber[LIFECYCLE] {
+	p := &HealthProber[LIFECYCLE]{
+		lifecycle: lifecycle,
+	}
+	p.interval.Store(int64(DefaultHealthCheckInterval))
+	p.timeout.Store(int64(DefaultHealthCheckTimeout))
+	p.failureThreshold.Store(DefaultHealthCheckFailureThreshold)
+	p.successThreshold.Store(DefaultHealthCheckSuccessThreshold)
+	p.health.Store(&Health{Status: HealthUnknown})
+	lifecycle.SetField(HealthProberFieldName, p)
+	p.Lifecycle = NewWithInterruptedStart(p.start)
+	return p
+}
+
+func (p *HealthProber[LIFECYCLE]) delegate() Lifecycle {
+	return p.Lifecycle
+}
+
+func (p *HealthProber[LIFECYCLE]) Get() LIFECYCLE {
+	return p.lifecycle
+}
+
+func (p *HealthProber[LIFECYCLE]) SetInterval(interval time.Duration) *HealthProber[LIFECYCLE] {
+	p.interval.Store(int64(interval))
+	return p
+}
+
+func (p *HealthProber[LIFECYCLE]) SetTimeout(timeout time.Duration) *HealthProber[LIFECYCLE] {
+	p.timeout.Store(int64(timeout))
+	return p
+}
+
+func (p *HealthProber[LIFECYCLE]) SetFailureThreshold(threshold int) *HealthProber[LIFECYCLE] {
+	p.failureThreshold.Store(int64(threshold))
+	return p
+}
+
+func (p *HealthProber[LIFECYCLE]) SetSuccessThreshold(threshold int) *HealthProber[LIFECYCLE] {
+	p.successThreshold.Store(int64(threshold))
+	return p
+}
+
+func (p *HealthProber[LIFECYCLE]) OnHealthChanged(onHealthChanged OnHealthChangedFunc) *HealthProber[LIFECYCLE] {
+	if onHealthChanged != nil {
+		lock.LockDo(&p.onHealthChangedLock, func() { p.onHealthChanged = append(p.onHealthChanged, onHealthChanged) })
+	}
+	return p
+}
+
+// Health 返回最近一次检查得到的健康状态，如果健康检查器没有运行，则被认为是不健康的
+func (p *HealthProber[LIFECYCLE]) Health() Health {
+	if !GetState(p.Lifecycle).Running() {
+		return stateHealth(p.Lifecycle)
+	}
+	return *p.health.Load()
+}
+
+func (p *HealthProber[LIFECYCLE]) doOnHealthChanged(old, new Health) {
+	lock.LockDo(&p.onHealthChangedLock, func() {
+		for _, callback := range p.onHealthChanged {
+			callback(p.lifecycle, old, new)
+		}
+	})
+}
+
+func (p *HealthProber[LIFECYCLE]) setHealth(health Health) {
+	old := p.health.Swap(&health)
+	if old.Status != health.Status {
+		p.doOnHealthChanged(*old, health)
+	}
+}
+
+func (p *HealthProber[LIFECYCLE]) update(err error, children map[string]Health) {
+	old := p.health.Load()
+	health := Health{Status: old.Status, Err: err, Time: time.Now(), Children: children}
+	if err != nil {
+		p.successes = 0
+		if p.failures++; p.failures >= p.failureThreshold.Load() {
+			health.Status = HealthUnhealthy
+		}
+	} else {
+		p.failures = 0
+		if p.successes++; p.successes >= p.successThreshold.Load() {
+			health.Status = HealthHealthy
+		}
+	}
+	p.setHealth(health)
+}
+
+func (p *HealthProber[LIFECYCLE]) check(interrupter chan struct{}) (err error, children map[string]Health) {
+	if checker, is := any(p.lifecycle).(HealthChecker); is {
+		return checker.CheckHealth(interrupter), nil
+	}
+	health := GetHealth(p.lifecycle)
+	if health.Status != HealthHealthy {
+		if err = health.Err; err == nil {
+			err = errors.New("生命周期组件不健康")
+		}
+	}
+	return err, health.Children
+}
+
+type healthCheckResult struct {
+	err      error
+	children map[string]Health
+}
+
+// probe 执行一次健康检查，如果检查过程中收到中断信号，则中断检查并返回true。检查超时
+// 或被中断时会向检查器发送中断信号，如果检查器忽略了中断信号，检查协程会一直运行到检查
+// 返回，此时下一次检查继续等待这次检查的结果而不会启动新的检查协程，所以同一时间最多只有
+// 一个检查协程在运行
+func (p *HealthProber[LIFECYCLE]) probe(interrupter chan struct{}) (interrupted bool) {
+	timeout := time.Duration(p.timeout.Load())
+	if p.checking == nil {
+		checkInterrupter := make(chan struct{}, 1)
+		checking := make(chan healthCheckResult, 1)
+		go func() {
+			err, children := p.check(checkInterrupter)
+			checking <- healthCheckResult{err: err, children: children}
+		}()
+		defer func() {
+			if p.checking != nil {
+				checkInterrupter <- struct{}{}
+			}
+		}()
+		p.checking = checking
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case r := <-p.checking:
+		p.checking = nil
+		p.update(r.err, r.children)
+	case <-timer.C:
+		p.update(NewHealthCheckTimeoutError("", timeout), nil)
+	case <-interrupter:
+		return true
+	}
+	return false
+}
+
+func (p *HealthProber[LIFECYCLE]) start(_ Lifecycle, interrupter chan struct{}) (InterruptedRunFunc, error) {
+	p.failures, p.successes = 0, 0
+	p.setHealth(Health{Status: HealthUnknown, Time: time.Now()})
+	runningFuture := AddRunningFuture(p.lifecycle, make(ChanFuture[error], 1))
+	if err := p.lifecycle.Background(); err != nil {
+		return nil, err
+	}
+	select {
+	case err := <-runningFuture:
+		if err != nil {
+			return nil, err
+		}
+		return p.run, nil
+	case <-interrupter:
+		closedFuture := make(ChanFuture[error], 1)
+		p.lifecycle.Close(closedFuture)
+		<-closedFuture
+		return nil, NewInterruptedError("健康检查器", "启动")
+	}
+}
+
+func (p *HealthProber[LIFECYCLE]) run(_ Lifecycle, interrupter chan struct{}) error {
+	closedFuture := AddClosedFuture(p.lifecycle, make(ChanFuture[error], 1))
+	probeTimer := time.NewTimer(0)
+	defer func() {
+		probeTimer.Stop()
+		p.setHealth(Health{Status: HealthUnhealthy, Err: NewStateNotRunningError(""), Time: time.Now()})
+	}()
+
+	closeLifecycle := func() {
+		p.lifecycle.Close(nil)
+		<-closedFuture
+	}
+
+	for {
+		select {
+		case <-probeTimer.C:
+			if p.probe(interrupter) {
+				closeLifecycle()
+				return nil
+			}
+			probeTimer.Reset(time.Duration(p.interval.Load()))
+		case err := <-closedFuture:
+			return err
+		case <-interrupter:
+			closeLifecycle()
+			return nil
+		}
+	}
+}
@@ -0,0 +1,218 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type HealthStatus int
+
+const (
+	HealthUnknown = HealthStatus(iota)
+	HealthHealthy
+	HealthUnhealthy
+)
+
+func (s HealthStatus) String() string {
+	switch s {
+	case HealthHealthy:
+		return "HEALTHY"
+	case HealthUnhealthy:
+		return "UNHEALTHY"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+func (s HealthStatus) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// worse 返回两个健康状态中更差的状态，UNHEALTHY 比 UNKNOWN 更差，UNKNOWN 比 HEALTHY 更差
+func (s HealthStatus) worse(o HealthStatus) HealthStatus {
+	rank := func(s HealthStatus) int {
+		switch s {
+		case HealthHealthy:
+			return 0
+		case HealthUnhealthy:
+			return 2
+		default:
+			return 1
+		}
+	}
+	if rank(o) > rank(s) {
+		return o
+	}
+	return s
+}
+
+type HealthCheckTimeoutError struct {
+	Target  string
+	Timeout time.Duration
+}
+
+func NewHealthCheckTimeoutError(target string, timeout time.Duration) *HealthCheckTimeoutError {
+	return &HealthCheckTimeoutError{Target: target, Timeout: timeout}
+}
+
+func (e *HealthCheckTimeoutError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Target == "" {
+		return fmt.Sprintf("生命周期组件健康检查超时(%s)", e.Timeout)
+	} else {
+		return fmt.Sprintf("%s健康检查超时(%s)", e.Target, e.Timeout)
+	}
+}
+
+// Health 为生命周期组件的健康状态，如果组件包含子组件，Children 中记录了每个子组件
+// 的健康状态
+type Health struct {
+	Status   HealthStatus      `json:"status"`
+	Err      error             `json:"-"`
+	Time     time.Time         `json:"time"`
+	Children map[string]Health `json:"children,omitempty"`
+}
+
+func (h Health) Healthy() bool {
+	return h.Status == HealthHealthy
+}
+
+func (h Health) MarshalJSON() ([]byte, error) {
+	type health Health
+	var msg string
+	if h.Err != nil {
+		msg = h.Err.Error()
+	}
+	return json.Marshal(struct {
+		health
+		Error string `json:"error,omitempty"`
+	}{health: health(h), Error: msg})
+}
+
+// HealthChecker 可以由生命周期组件实现，用于检查组件是否健康，检查过程中如果收到中断
+// 信号，需要尽快返回
+type HealthChecker interface {
+	CheckHealth(interrupter chan struct{}) error
+}
+
+// HealthReporter 由可以直接给出健康状态的生命周期组件实现，例如 HealthProber、Group
+// 和 List
+type HealthReporter interface {
+	Health() Health
+}
+
+type OnHealthChangedFunc = func(lifecycle Lifecycle, old, new Health)
+
+// GetHealth 获取生命周期组件的健康状态，如果组件实现了 HealthReporter，则直接使用组件
+// 报告的健康状态，否则根据组件的状态判断，运行中的组件被认为是健康的
+func GetHealth(lifecycle Lifecycle) Health {
+	if reporter, ok := lookup[HealthReporter](lifecycle); ok {
+		return reporter.Health()
+	}
+	return stateHealth(lifecycle)
+}
+
+type healthHandler struct {
+	lifecycle Lifecycle
+}
+
+// HealthHandler 返回以 JSON 格式输出组件健康状态的 http.Handler，组件健康时响应状态码为
+// 200，否则为 503，可以直接作为存活或就绪探针的地址
+func HealthHandler(lifecycle Lifecycle) http.Handler {
+	return healthHandler{lifecycle: lifecycle}
+}
+
+func (h healthHandler) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	health := GetHealth(h.lifecycle)
+	data, err := json.Marshal(health)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	if !health.Healthy() {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	w.Write(data)
+}
+
+func stateHealth(lifecycle Lifecycle) Health {
+	switch state := GetState(lifecycle); {
+	case state.Running():
+		return Health{Status: HealthHealthy, Time: time.Now()}
+	case state.Starting(), state.Started():
+		// 暂停的组件既不是健康的也不是不健康的
+		return Health{Status: HealthUnknown, Time: time.Now()}
+	default:
+		return Health{Status: HealthUnhealthy, Err: NewStateNotRunningError(""), Time: time.Now()}
+	}
+}
+
+// aggregateHealth 汇总子组件的健康状态，汇总后的状态为所有子组件中最差的状态。如果父
+// 组件本身没有运行，则父组件的状态为不健康
+func aggregateHealth(parent Lifecycle, children map[string]Health) Health {
+	health := stateHealth(parent)
+	if health.Status != HealthHealthy {
+		health.Children = children
+		return health
+	}
+	for _, child := range children {
+		health.Status = health.Status.worse(child.Status)
+	}
+	health.Children = children
+	return health
+}
+
+func (h *GroupLifecycleHolder) unwrap() Lifecycle {
+	return h.Lifecycle
+}
+
+func (h *ListLifecycleHolder) unwrap() Lifecycle {
+	return h.Lifecycle
+}
+
+func (h *GraphLifecycleHolder) unwrap() Lifecycle {
+	return h.Lifecycle
+}
+
+func (h *SupervisorLifecycleHolder) unwrap() Lifecycle {
+	return h.Lifecycle
+}
+
+func (g *Group) Health() Health {
+	children := make(map[string]Health)
+	for _, child := range g.getChildren(false) {
+		if !child.removed.Load() {
+			children[child.name] = GetHealth(child.Lifecycle)
+		}
+	}
+	return aggregateHealth(g.lifecycle, children)
+}
+
+func (l *List) Health() Health {
+	children := make(map[string]Health)
+	for _, child := range l.getChildren() {
+		children[child.name()] = GetHealth(child.Lifecycle)
+	}
+	return aggregateHealth(l.lifecycle, children)
+}
+
+func (g *Graph) Health() Health {
+	children := make(map[string]Health)
+	for _, child := range g.getChildren() {
+		children[child.name] = GetHealth(child.Lifecycle)
+	}
+	return aggregateHealth(g.lifecycle, children)
+}
+
+func (s *Supervisor) Health() Health {
+	children := make(map[string]Health)
+	for _, child := range s.getChildren() {
+		children[child.name] = GetHealth(child.Lifecycle)
+	}
+	return aggregateHealth(s.lifecycle, children)
+}
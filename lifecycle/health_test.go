@@ -0,0 +1,131 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+type healthTestLifecycle struct {
+	Lifecycle
+	healthy atomic.Bool
+}
+
+func (l *healthTestLifecycle) CheckHealth(chan struct{}) error {
+	if !l.healthy.Load() {
+		return errors.New("unhealthy")
+	}
+	return nil
+}
+
+func TestHealthProber(t *testing.T) {
+	l := &healthTestLifecycle{Lifecycle: NewWithInterruptedRun(nil, InterrupterHoldRun)}
+	l.healthy.Store(true)
+	changed := make(chan HealthStatus, 4)
+	prober := NewHealthProber(l).
+		SetInterval(time.Millisecond * 10).
+		SetFailureThreshold(2).
+		OnHealthChanged(func(_ Lifecycle, _, new Health) { changed <- new.Status })
+	g := NewGroup()
+	g.MustAdd("test", prober)
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+
+	expect := func(status HealthStatus) {
+		select {
+		case s := <-changed:
+			if s != status {
+				t.Fatalf("expect health status %s, got %s", status, s)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("wait health status %s timeout", status)
+		}
+	}
+	expect(HealthHealthy)
+	if health := g.Health(); health.Status != HealthHealthy || health.Children["test"].Status != HealthHealthy {
+		t.Fatalf("unexpected group health %+v", health)
+	}
+	l.healthy.Store(false)
+	expect(HealthUnhealthy)
+	if health := g.Health(); health.Status != HealthUnhealthy {
+		t.Fatalf("unexpected group health %+v", health)
+	}
+	l.healthy.Store(true)
+	expect(HealthHealthy)
+}
+
+type stuckHealthLifecycle struct {
+	Lifecycle
+	checking    atomic.Int64
+	maxChecking atomic.Int64
+	release     chan struct{}
+}
+
+func (l *stuckHealthLifecycle) CheckHealth(chan struct{}) error {
+	if n := l.checking.Add(1); n > l.maxChecking.Load() {
+		l.maxChecking.Store(n)
+	}
+	defer l.checking.Add(-1)
+	// 忽略中断信号
+	<-l.release
+	return nil
+}
+
+func TestHealthProberStuckChecker(t *testing.T) {
+	l := &stuckHealthLifecycle{Lifecycle: NewWithInterruptedRun(nil, InterrupterHoldRun), release: make(chan struct{})}
+	prober := NewHealthProber(l).
+		SetInterval(time.Millisecond * 5).
+		SetTimeout(time.Millisecond * 5).
+		SetFailureThreshold(1)
+	if err := prober.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 100)
+	if health := prober.Health(); health.Status != HealthUnhealthy {
+		t.Errorf("expect unhealthy after check timeout, got %s", health.Status)
+	}
+	if n := l.maxChecking.Load(); n != 1 {
+		t.Errorf("expect at most one check running, got %d", n)
+	}
+	close(l.release)
+	prober.Shutdown()
+}
+
+func TestHealthHandler(t *testing.T) {
+	l := NewWithInterruptedRun(nil, InterrupterHoldRun)
+	handler := HealthHandler(l)
+	serve := func() (int, Health) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/health", nil))
+		var health struct {
+			Status string `json:"status"`
+		}
+		if err := json.Unmarshal(recorder.Body.Bytes(), &health); err != nil {
+			t.Fatal(err)
+		}
+		status := HealthUnknown
+		switch health.Status {
+		case "HEALTHY":
+			status = HealthHealthy
+		case "UNHEALTHY":
+			status = HealthUnhealthy
+		}
+		return recorder.Code, Health{Status: status}
+	}
+	if code, health := serve(); code != http.StatusServiceUnavailable || health.Status != HealthUnhealthy {
+		t.Errorf("closed lifecycle should be unhealthy, got %d %s", code, health.Status)
+	}
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer l.Shutdown()
+	if code, health := serve(); code != http.StatusOK || health.Status != HealthHealthy {
+		t.Errorf("running lifecycle should be healthy, got %d %s", code, health.Status)
+	}
+}
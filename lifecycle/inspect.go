@@ -0,0 +1,227 @@
+package lifecycle
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TreeChild 为生命周期组件树中的子组件
+type TreeChild struct {
+	Name      string
+	Lifecycle Lifecycle
+}
+
+// TreeNode 由包含子组件的生命周期组件实现，用于遍历生命周期组件树
+type TreeNode interface {
+	TreeChildren() []TreeChild
+}
+
+// Node 为生命周期组件树中一个组件的快照
+type Node struct {
+	Name     string         `json:"name"`
+	Type     string         `json:"type"`
+	State    State          `json:"state"`
+	Uptime   time.Duration  `json:"-"`
+	Err      error          `json:"-"`
+	Fields   map[string]any `json:"fields,omitempty"`
+	Children []*Node        `json:"children,omitempty"`
+}
+
+func (n *Node) MarshalJSON() ([]byte, error) {
+	type node Node
+	var msg string
+	if n.Err != nil {
+		msg = n.Err.Error()
+	}
+	return json.Marshal(struct {
+		*node
+		Uptime string                     `json:"uptime,omitempty"`
+		Error  string                     `json:"error,omitempty"`
+		Fields map[string]json.RawMessage `json:"fields,omitempty"`
+	}{node: (*node)(n), Uptime: formatUptime(n.Uptime), Error: msg, Fields: marshalFields(n.Fields)})
+}
+
+// marshalFields 分别序列化每个属性，无法序列化的属性(例如通道、函数或者循环引用的值)
+// 使用属性的类型代替，不影响其他属性和整个组件树的序列化
+func marshalFields(fields map[string]any) map[string]json.RawMessage {
+	if len(fields) == 0 {
+		return nil
+	}
+	marshalled := make(map[string]json.RawMessage, len(fields))
+	for name, value := range fields {
+		data, err := json.Marshal(value)
+		if err != nil {
+			data, _ = json.Marshal("<" + fmt.Sprintf("%T", value) + ">")
+		}
+		marshalled[name] = data
+	}
+	return marshalled
+}
+
+func formatUptime(uptime time.Duration) string {
+	if uptime == 0 {
+		return ""
+	}
+	return uptime.Truncate(time.Millisecond).String()
+}
+
+func unwrapHolder(lifecycle Lifecycle) Lifecycle {
+	for {
+		holder, is := lifecycle.(interface{ unwrap() Lifecycle })
+		if !is {
+			return lifecycle
+		}
+		lifecycle = holder.unwrap()
+	}
+}
+
+// Inspect 从根组件开始遍历生命周期组件树，获取每个组件的名称、状态、运行时间、最近一次
+// 的错误和属性。以'$'开头的属性为内部属性，不会被记录
+func Inspect(root Lifecycle) *Node {
+	return inspect(GetName(root), root)
+}
+
+func inspect(name string, lifecycle Lifecycle) *Node {
+	lifecycle = unwrapHolder(lifecycle)
+	node := &Node{
+		Name:   name,
+		Type:   fmt.Sprintf("%T", lifecycle),
+		State:  GetState(lifecycle),
+		Uptime: GetUptime(lifecycle),
+		Err:    lifecycle.Error(),
+	}
+	lifecycle.RangeField(func(name string, value any) bool {
+		if !strings.HasPrefix(name, "$") {
+			if node.Fields == nil {
+				node.Fields = make(map[string]any)
+			}
+			node.Fields[name] = value
+		}
+		return true
+	})
+	if tree, is := lifecycle.(TreeNode); is {
+		for _, child := range tree.TreeChildren() {
+			node.Children = append(node.Children, inspect(child.Name, child.Lifecycle))
+		}
+	}
+	return node
+}
+
+func (n *Node) writeText(w io.Writer, prefix, childPrefix string) error {
+	name := n.Name
+	if name == "" {
+		name = "<" + n.Type + ">"
+	}
+	line := prefix + name + " [" + n.State.String() + "]"
+	if uptime := formatUptime(n.Uptime); uptime != "" {
+		line += " uptime=" + uptime
+	}
+	if n.Err != nil {
+		line += " error=" + strconv.Quote(n.Err.Error())
+	}
+	if len(n.Fields) > 0 {
+		names := make([]string, 0, len(n.Fields))
+		for name := range n.Fields {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			line += fmt.Sprintf(" %s=%v", name, n.Fields[name])
+		}
+	}
+	if _, err := io.WriteString(w, line+"\n"); err != nil {
+		return err
+	}
+	for i, child := range n.Children {
+		if i == len(n.Children)-1 {
+			if err := child.writeText(w, childPrefix+"└── ", childPrefix+"    "); err != nil {
+				return err
+			}
+		} else {
+			if err := child.writeText(w, childPrefix+"├── ", childPrefix+"│   "); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// WriteText 将组件树以缩进的文本格式写入
+func (n *Node) WriteText(w io.Writer) error {
+	return n.writeText(w, "", "")
+}
+
+func (n *Node) String() string {
+	sb := strings.Builder{}
+	n.WriteText(&sb)
+	return sb.String()
+}
+
+// DumpText 遍历生命周期组件树并以缩进的文本格式写入
+func DumpText(w io.Writer, root Lifecycle) error {
+	return Inspect(root).WriteText(w)
+}
+
+// DumpJSON 遍历生命周期组件树并以JSON格式写入
+func DumpJSON(w io.Writer, root Lifecycle) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(Inspect(root))
+}
+
+func (g *Group) TreeChildren() []TreeChild {
+	var children []TreeChild
+	for _, child := range g.getChildren(false) {
+		if !child.removed.Load() {
+			children = append(children, TreeChild{Name: child.name, Lifecycle: child.Lifecycle})
+		}
+	}
+	sort.Slice(children, func(i, j int) bool { return children[i].Name < children[j].Name })
+	return children
+}
+
+func (l *List) TreeChildren() []TreeChild {
+	var children []TreeChild
+	for _, child := range l.getChildren() {
+		children = append(children, TreeChild{Name: child.name(), Lifecycle: child.Lifecycle})
+	}
+	return children
+}
+
+func (g *Graph) TreeChildren() []TreeChild {
+	var children []TreeChild
+	for _, child := range g.getChildren() {
+		children = append(children, TreeChild{Name: child.name, Lifecycle: child.Lifecycle})
+	}
+	return children
+}
+
+func (s *Supervisor) TreeChildren() []TreeChild {
+	var children []TreeChild
+	for _, child := range s.getChildren() {
+		children = append(children, TreeChild{Name: child.name, Lifecycle: child.Lifecycle})
+	}
+	return children
+}
+
+// wrappedChild 返回包装器组件中被包装的子组件，如果子组件没有名称，则使用"lifecycle"
+func wrappedChild(lifecycle Lifecycle) []TreeChild {
+	name := GetName(lifecycle)
+	if name == "" {
+		name = "lifecycle"
+	}
+	return []TreeChild{{Name: name, Lifecycle: lifecycle}}
+}
+
+func (r *Retryable[LIFECYCLE]) TreeChildren() []TreeChild {
+	return wrappedChild(r.lifecycle)
+}
+
+func (p *HealthProber[LIFECYCLE]) TreeChildren() []TreeChild {
+	return wrappedChild(p.lifecycle)
+}
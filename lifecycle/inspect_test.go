@@ -0,0 +1,98 @@
+package lifecycle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestInspect(t *testing.T) {
+	inner := NewList()
+	inner.MustAppend(NewWithInterruptedRun(nil, InterrupterHoldRun, WithName("worker")))
+	inner.MustAppend(NewWithInterruptedRun(nil, InterrupterHoldRun))
+	root := NewGroup()
+	root.SetField("version", "1.0")
+	root.MustAdd("db", NewWithInterruptedRun(nil, InterrupterHoldRun))
+	root.MustAdd("workers", inner)
+	root.MustAdd("retryable", NewRetryable(NewWithInterruptedRun(nil, InterrupterHoldRun, WithName("conn"))))
+	if err := root.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer root.Shutdown()
+
+	node := Inspect(root)
+	if len(node.Children) != 3 || node.Fields["version"] != "1.0" {
+		t.Fatalf("unexpected root node %+v", node)
+	}
+	text := node.String()
+	for _, s := range []string{"├── db [RUNNING]", "│   └── conn [RUNNING]", "    ├── worker [RUNNING]", "    └── 1 [RUNNING]", "version=1.0"} {
+		if !strings.Contains(text, s) {
+			t.Errorf("expect %q in dump:\n%s", s, text)
+		}
+	}
+
+	buf := bytes.Buffer{}
+	if err := DumpJSON(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]any
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	if decoded["state"] != "RUNNING" || decoded["uptime"] == nil {
+		t.Errorf("unexpected json dump %s", buf.String())
+	}
+
+	// 无法序列化的属性不影响其他属性
+	type cyclic struct{ Next *cyclic }
+	loop := &cyclic{}
+	loop.Next = loop
+	root.SetField("chan", make(chan int))
+	root.SetField("func", func() {})
+	root.SetField("cyclic", loop)
+	buf.Reset()
+	if err := DumpJSON(&buf, root); err != nil {
+		t.Fatal(err)
+	}
+	decoded = nil
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatal(err)
+	}
+	fields, _ := decoded["fields"].(map[string]any)
+	if fields["version"] != "1.0" || fields["chan"] != "<chan int>" || fields["func"] != "<func()>" {
+		t.Errorf("unexpected fields %v", fields)
+	}
+}
+
+// plainLifecycle 只实现了 Lifecycle 接口，没有实现任何可选接口
+type plainLifecycle struct {
+	Lifecycle
+}
+
+func TestOptionalInterfaces(t *testing.T) {
+	child := NewWithInterruptedRun(nil, InterrupterHoldRun)
+	g := NewGroup()
+	holder := g.MustAdd("child", child)
+	plain := plainLifecycle{Lifecycle: NewWithInterruptedRun(nil, InterrupterHoldRun)}
+	g.MustAdd("plain", plain)
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+
+	if !GetState(g).Running() || !GetState(holder).Running() || GetUptime(holder) <= 0 {
+		t.Errorf("optional interfaces should be found through group and holder")
+	}
+	if GetContext(holder).Err() != nil {
+		t.Errorf("context of running child should not be canceled")
+	}
+	if !GetState(plain).Closed() || GetContext(plain).Err() != nil {
+		t.Errorf("unexpected fallback state %s", GetState(plain))
+	}
+	var notSupported *PauseNotSupportedError
+	if err := Pause(plain); !errors.As(err, &notSupported) {
+		t.Errorf("expect pause not supported error, got %v", err)
+	}
+}
@@ -1,6 +1,9 @@
 package lifecycle
 
-import "gitee.com/sy_183/common/utils"
+import (
+	"gitee.com/sy_183/common/utils"
+	"sync"
+)
 
 type InterruptedRunner interface {
 	DoStart(lifecycle Lifecycle, interrupter chan struct{}) error
@@ -46,18 +49,52 @@ type canInterrupted interface {
 	ToClosing()
 }
 
+// interruptEscalator 在组件关闭超时后再次向运行函数发送中断信号，运行函数在处理关闭的
+// 过程中可以再次等待中断信号，收到后放弃剩余的关闭流程。只有运行函数正在运行时才发送，
+// 避免中断信号残留到组件下一次运行
+type interruptEscalator struct {
+	interrupter chan struct{}
+	running     bool
+	mu          sync.Mutex
+}
+
+func (e *interruptEscalator) enter() {
+	e.mu.Lock()
+	e.running = true
+	e.mu.Unlock()
+}
+
+// exit 在运行函数返回后调用，清除未被处理的中断信号
+func (e *interruptEscalator) exit() {
+	e.mu.Lock()
+	e.running = false
+	utils.ChanTryPop(e.interrupter)
+	e.mu.Unlock()
+}
+
+func (e *interruptEscalator) escalate() {
+	e.mu.Lock()
+	if e.running {
+		utils.ChanTryPush(e.interrupter, struct{}{})
+	}
+	e.mu.Unlock()
+}
+
 type interruptedRunner struct {
 	canInterrupted canInterrupted
 	interrupter    chan struct{}
+	escalator      interruptEscalator
 	runner         InterruptedRunner
 }
 
 func newInterrupterRunner(canInterrupted canInterrupted, runner InterruptedRunner) *interruptedRunner {
-	return &interruptedRunner{
+	r := &interruptedRunner{
 		canInterrupted: canInterrupted,
 		interrupter:    make(chan struct{}, 1),
 		runner:         runner,
 	}
+	r.escalator.interrupter = r.interrupter
+	return r
 }
 
 func (r *interruptedRunner) DoStart(lifecycle Lifecycle) error {
@@ -69,7 +106,8 @@ func (r *interruptedRunner) DoStart(lifecycle Lifecycle) error {
 }
 
 func (r *interruptedRunner) DoRun(lifecycle Lifecycle) error {
-	defer utils.ChanTryPop(r.interrupter)
+	r.escalator.enter()
+	defer r.escalator.exit()
 	return r.runner.DoRun(lifecycle, r.interrupter)
 }
 
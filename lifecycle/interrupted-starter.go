@@ -26,16 +26,19 @@ func (f interruptedStarterFunc) DoStart(lifecycle Lifecycle, interrupter chan st
 type interruptedStarter struct {
 	canInterrupted canInterrupted
 	interrupter    chan struct{}
+	escalator      interruptEscalator
 	starter        InterruptedStarter
 	runFn          InterruptedRunFunc
 }
 
 func newInterruptedStarter(canInterrupted canInterrupted, starter InterruptedStarter) *interruptedStarter {
-	return &interruptedStarter{
+	s := &interruptedStarter{
 		canInterrupted: canInterrupted,
 		interrupter:    make(chan struct{}, 1),
 		starter:        starter,
 	}
+	s.escalator.interrupter = s.interrupter
+	return s
 }
 
 func (s *interruptedStarter) Runner() Runner {
@@ -58,7 +61,8 @@ func (s *interruptedStarter) start(lifecycle Lifecycle) (err error) {
 }
 
 func (s *interruptedStarter) run(lifecycle Lifecycle) (err error) {
-	defer utils.ChanTryPop(s.interrupter)
+	s.escalator.enter()
+	defer s.escalator.exit()
 	defer s.canInterrupted.setRunner(s.Runner())
 	if s.runFn != nil {
 		return s.runFn(lifecycle, s.interrupter)
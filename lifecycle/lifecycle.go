@@ -1,11 +1,13 @@
 package lifecycle
 
 import (
+	"context"
 	"fmt"
 	"gitee.com/sy_183/common/errors"
 	"gitee.com/sy_183/common/lock"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 type (
@@ -67,6 +69,57 @@ type Lifecycle interface {
 	Error() error
 }
 
+// StateLoader 由可以获取当前状态的生命周期组件实现
+type StateLoader interface {
+	LoadState() State
+}
+
+// UptimeLoader 由可以获取运行时间的生命周期组件实现
+type UptimeLoader interface {
+	Uptime() time.Duration
+}
+
+// lifecycleDelegator 由将生命周期委托给内部 DefaultLifecycle 的组件实现，例如生命周期
+// 组、列表和图，用于查找组件实现的可选接口
+type lifecycleDelegator interface {
+	delegate() Lifecycle
+}
+
+// lookup 查找组件实现的可选接口，如果组件本身没有实现，则依次查找被持有的子组件和组件
+// 委托的生命周期
+func lookup[T any](lifecycle Lifecycle) (t T, ok bool) {
+	for lifecycle != nil {
+		if t, ok = lifecycle.(T); ok {
+			return
+		}
+		switch l := lifecycle.(type) {
+		case interface{ unwrap() Lifecycle }:
+			lifecycle = l.unwrap()
+		case lifecycleDelegator:
+			lifecycle = l.delegate()
+		default:
+			return
+		}
+	}
+	return
+}
+
+// GetState 获取组件的状态，组件没有实现 StateLoader 时返回 StateClosed
+func GetState(lifecycle Lifecycle) State {
+	if loader, ok := lookup[StateLoader](lifecycle); ok {
+		return loader.LoadState()
+	}
+	return StateClosed
+}
+
+// GetUptime 获取组件进入运行状态后经过的时间，组件没有实现 UptimeLoader 时返回0
+func GetUptime(lifecycle Lifecycle) time.Duration {
+	if loader, ok := lookup[UptimeLoader](lifecycle); ok {
+		return loader.Uptime()
+	}
+	return 0
+}
+
 func AddRunningFuture[FUTURE Future[error]](lifecycle Lifecycle, future FUTURE) FUTURE {
 	lifecycle.AddStartedFuture(future)
 	return future
@@ -127,12 +180,28 @@ type defaultLifecycle interface {
 type DefaultLifecycle struct {
 	this   defaultLifecycle
 	runner Runner
+	pauser Pausable
+	// 关闭超时后再次中断运行函数的方式，只有使用中断信号运行的组件才会设置
+	escalator *interruptEscalator
 
 	runningFutures SyncFutures[error]
 	closedFutures  SyncFutures[error]
 
 	err atomic.Pointer[error]
 
+	// 组件进入运行状态的时间，组件未运行时为0
+	runningTime atomic.Int64
+
+	metrics lifecycleMetrics
+
+	closeCtx atomic.Pointer[context.Context]
+	// 组件本次运行的上下文，在组件开始关闭时被取消
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// 组件关闭的过程是否受关闭上下文的限制，如果是，则 ShutdownContext 总是等待组件退出
+	boundedClose bool
+
 	onStarting     []OnStartingFunc
 	onStartingLock sync.Mutex
 
@@ -147,6 +216,10 @@ type DefaultLifecycle struct {
 
 	fields sync.Map
 
+	observers observers
+	events    []StateEvent
+	notifying bool
+
 	State
 	sync.RWMutex
 }
@@ -165,6 +238,14 @@ func NewWithRun(startFn StartFunc, runFn RunFunc, closeFn CloseFunc, options ...
 	return NewWithRunner(FuncRunner(startFn, runFn, closeFn), options...)
 }
 
+func NewWithContextRunner(runner ContextRunner, options ...Option) *DefaultLifecycle {
+	return New(append(options, WithContextRunner(runner))...)
+}
+
+func NewWithContextRun(startFn ContextStartFunc, runFn ContextRunFunc, options ...Option) *DefaultLifecycle {
+	return NewWithContextRunner(FuncContextRunner(startFn, runFn), options...)
+}
+
 func NewWithInterruptedRunner(runner InterruptedRunner, options ...Option) *DefaultLifecycle {
 	return New(append(options, WithInterruptedRunner(runner))...)
 }
@@ -213,14 +294,22 @@ func (l *DefaultLifecycle) setRunner(runner Runner) {
 	l.runner = runner
 }
 
+func (l *DefaultLifecycle) setEscalator(escalator *interruptEscalator) {
+	l.escalator = escalator
+}
+
 func (l *DefaultLifecycle) doRun() error {
 	err := l.runner.DoRun(l.self())
 	l.err.Store(&err)
 	l.doOnClosed(err)
-	lock.LockGet[Futures[error]](l, func() Futures[error] {
-		l.ToClosed()
+	closedFutures := lock.LockGet[Futures[error]](l, func() Futures[error] {
+		l.switchState(StateClosed, err)
+		l.cancelContext()
+		l.closeCtx.Store(nil)
 		return l.closedFutures.LoadAndReset()
-	}).Complete(err)
+	})
+	l.notifyEvents()
+	closedFutures.Complete(err)
 	return err
 }
 
@@ -261,10 +350,13 @@ func (l *DefaultLifecycle) preStart() (runnable bool, err error) {
 			return
 		}
 		runnable = true
-		l.ToStarting()
+		l.switchState(StateStarting, nil)
+		l.newContext()
+		l.closeCtx.Store(nil)
 		return
 	})
 	if runnable {
+		l.notifyEvents()
 		l.doOnStarting()
 	}
 	return
@@ -276,21 +368,26 @@ func (l *DefaultLifecycle) doStart() (runnable bool, err error) {
 		l.err.Store(&err)
 		l.doOnStarted(err)
 		runningFutures, closedFutures := lock.LockGetDouble(l, func() (Futures[error], Futures[error]) {
-			l.ToClosed()
+			l.switchState(StateClosed, err)
+			l.cancelContext()
+			l.closeCtx.Store(nil)
 			return l.runningFutures.LoadAndReset(), l.closedFutures.LoadAndReset()
 		})
+		l.notifyEvents()
 		runningFutures.Complete(err)
 		closedFutures.Complete(err)
 		return
 	}
 	l.doOnStarted(nil)
-	lock.LockGet(l, func() Futures[error] {
+	runningFutures := lock.LockGet(l, func() Futures[error] {
 		if !l.Closing() {
-			l.ToRunning()
+			l.switchState(StateRunning, nil)
 		}
 		runnable = true
 		return l.runningFutures.LoadAndReset()
-	}).Complete(nil)
+	})
+	l.notifyEvents()
+	runningFutures.Complete(nil)
 	return
 }
 
@@ -325,7 +422,7 @@ func (l *DefaultLifecycle) doClose(future Future[error]) error {
 	if err := l.runner.DoClose(l.self()); err != nil {
 		return err
 	}
-	l.ToClosing()
+	l.markClosing()
 	l.closedFutures.Append(future)
 	return nil
 }
@@ -344,6 +441,7 @@ func (l *DefaultLifecycle) Close(future Future[error]) error {
 		return l.self().Error()
 	}); !closed {
 		if !closing {
+			l.notifyEvents()
 			l.doOnClose(err)
 		}
 		return err
@@ -372,7 +470,7 @@ func (l *DefaultLifecycle) AddStartedFuture(future Future[error]) Future[error]
 		return nil
 	}
 	if lock.RLockGet(l, func() (completed bool) {
-		if completed = l.Running() || l.Closing(); !completed {
+		if completed = l.Started() || l.Closing(); !completed {
 			l.runningFutures.Append(future)
 		}
 		return
@@ -549,6 +647,18 @@ func (l *DefaultLifecycle) Error() error {
 	return nil
 }
 
+func (l *DefaultLifecycle) LoadState() State {
+	return lock.RLockGet(l, func() State { return l.State })
+}
+
+// Uptime 返回组件进入运行状态后经过的时间，如果组件没有运行，则返回0
+func (l *DefaultLifecycle) Uptime() time.Duration {
+	if t := l.runningTime.Load(); t != 0 {
+		return time.Since(time.Unix(0, t))
+	}
+	return 0
+}
+
 func (l *DefaultLifecycle) String() string {
-	return fmt.Sprintf("生命周期组件(%p)[%s]", l, lock.RLockGet(l, func() State { return l.State }))
+	return fmt.Sprintf("生命周期组件(%p)[%s]", l, l.LoadState())
 }
@@ -3,6 +3,7 @@ package lifecycle
 import (
 	"gitee.com/sy_183/common/assert"
 	"gitee.com/sy_183/common/lock"
+	"strconv"
 	"sync"
 	"sync/atomic"
 )
@@ -77,10 +78,16 @@ func NewList() *List {
 		closedChannel: newChildLifecycleChannel[*ListLifecycleHolder](),
 	}
 	l.lifecycle = NewWithInterruptedStart(l.start)
+	l.lifecycle.boundedClose = true
+	l.lifecycle.pauser = FuncPauser(l.pause, l.resume)
 	l.Lifecycle = l.lifecycle
 	return l
 }
 
+func (l *List) delegate() Lifecycle {
+	return l.lifecycle
+}
+
 func (l *List) Append(lifecycle Lifecycle) (*ListLifecycleHolder, error) {
 	return lock.RLockGetDouble(l.lifecycle, func() (*ListLifecycleHolder, error) {
 		if !l.lifecycle.Closed() {
@@ -107,7 +114,21 @@ func (l *List) MustAppend(lifecycle Lifecycle) *ListLifecycleHolder {
 	return assert.Must(l.Append(lifecycle))
 }
 
-func (l *List) shutdownChildren(children []*ListLifecycleHolder, exclude map[*ListLifecycleHolder]struct{}) {
+// name 返回子组件在列表中的名称，子组件没有设置名称时使用子组件的索引
+func (h *ListLifecycleHolder) name() string {
+	if name := GetName(h.Lifecycle); name != "" {
+		return name
+	}
+	return strconv.Itoa(h.index)
+}
+
+func (l *List) getChildren() []*ListLifecycleHolder {
+	return lock.RLockGet(l.lifecycle, func() []*ListLifecycleHolder {
+		return append([]*ListLifecycleHolder(nil), l.children...)
+	})
+}
+
+func (l *List) shutdownChildren(children []*ListLifecycleHolder, exclude map[*ListLifecycleHolder]struct{}) (stuck []string) {
 	excluded := func(child *ListLifecycleHolder) bool {
 		if exclude != nil {
 			_, has := exclude[child]
@@ -115,13 +136,26 @@ func (l *List) shutdownChildren(children []*ListLifecycleHolder, exclude map[*Li
 		}
 		return false
 	}
+	ctx := l.lifecycle.closeContext()
 	for i := len(children) - 1; i >= 0; i-- {
-		if !excluded(children[i]) {
-			future := make(ChanFuture[error], 1)
-			children[i].Close(future)
-			<-future
+		if excluded(children[i]) {
+			continue
+		}
+		if ctx.Err() != nil {
+			// 关闭超时，不再按照顺序等待子组件关闭，直接中断剩余的所有子组件
+			var names []string
+			var remaining []Lifecycle
+			for ; i >= 0; i-- {
+				if !excluded(children[i]) {
+					names = append(names, children[i].name())
+					remaining = append(remaining, children[i])
+				}
+			}
+			return append(stuck, interruptChildren(ctx, names, remaining)...)
 		}
+		stuck = append(stuck, stuckChildren(children[i].name(), ShutdownContext(children[i], ctx))...)
 	}
+	return
 }
 
 func (l *List) handleClosedSignal() (closeAll bool) {
@@ -195,7 +229,9 @@ next:
 					return nil, NewInterruptedError("生命周期列表", "启动")
 				}
 			case <-interrupter:
-				l.shutdownChildren(l.children[:l.started], nil)
+				if stuck := l.shutdownChildren(l.children[:l.started], nil); len(stuck) > 0 {
+					return nil, NewShutdownTimeoutError("生命周期列表", stuck)
+				}
 				return nil, NewInterruptedError("生命周期列表", "启动")
 			}
 		}
@@ -212,7 +248,9 @@ func (l *List) run(_ Lifecycle, interrupter chan struct{}) error {
 				return nil
 			}
 		case <-interrupter:
-			l.shutdownChildren(l.children, nil)
+			if stuck := l.shutdownChildren(l.children, nil); len(stuck) > 0 {
+				return NewShutdownTimeoutError("生命周期列表", stuck)
+			}
 			return nil
 		}
 	}
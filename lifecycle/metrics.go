@@ -0,0 +1,243 @@
+package lifecycle
+
+import (
+	"bufio"
+	"gitee.com/sy_183/common/lock"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Metrics 为生命周期组件的启动、运行和关闭的耗时以及次数统计，对于包含子组件的组件，
+// Children 中记录了每个子组件的统计信息
+type Metrics struct {
+	State         State              `json:"state"`
+	StartDuration time.Duration      `json:"startDuration"`
+	RunDuration   time.Duration      `json:"runDuration"`
+	CloseDuration time.Duration      `json:"closeDuration"`
+	Starts        int64              `json:"starts"`
+	StartErrors   int64              `json:"startErrors"`
+	ExitErrors    int64              `json:"exitErrors"`
+	Restarts      int64              `json:"restarts"`
+	Children      map[string]Metrics `json:"children,omitempty"`
+}
+
+// lifecycleMetrics 记录组件状态切换的时间，只在持有组件的锁时访问
+type lifecycleMetrics struct {
+	Metrics
+	startTime   time.Time
+	runningTime time.Time
+	closingTime time.Time
+}
+
+func (m *lifecycleMetrics) record(from, to State, err error, now time.Time) {
+	switch to {
+	case StateStarting:
+		m.startTime = now
+		m.Starts++
+	case StateRunning:
+		if from.Starting() {
+			m.StartDuration = now.Sub(m.startTime)
+			m.runningTime = now
+		}
+	case StateClosing:
+		m.closingTime = now
+	case StateClosed:
+		switch {
+		case from.Starting():
+			m.StartDuration = now.Sub(m.startTime)
+			m.StartErrors++
+		case from.Closing():
+			m.CloseDuration = now.Sub(m.closingTime)
+			if !m.runningTime.IsZero() {
+				m.RunDuration = m.closingTime.Sub(m.runningTime)
+			}
+			// 被关闭的组件退出时同样可能返回错误
+			if err != nil {
+				m.ExitErrors++
+			}
+		default:
+			m.RunDuration = now.Sub(m.runningTime)
+			if err != nil {
+				m.ExitErrors++
+			}
+		}
+		m.runningTime = time.Time{}
+	}
+}
+
+// MetricsProvider 由可以提供统计信息的生命周期组件实现
+type MetricsProvider interface {
+	Metrics() Metrics
+}
+
+// GetMetrics 获取组件的统计信息，组件没有实现 MetricsProvider 时只包含组件的状态
+func GetMetrics(lifecycle Lifecycle) Metrics {
+	if provider, ok := lookup[MetricsProvider](lifecycle); ok {
+		return provider.Metrics()
+	}
+	return Metrics{State: GetState(lifecycle)}
+}
+
+// Metrics 返回组件的统计信息，如果组件正在运行，运行时间为组件进入运行状态后经过的时间
+func (l *DefaultLifecycle) Metrics() Metrics {
+	return lock.RLockGet(l, func() Metrics {
+		m := l.metrics.Metrics
+		m.State = l.State
+		if !l.metrics.runningTime.IsZero() {
+			if l.Closing() {
+				m.RunDuration = l.metrics.closingTime.Sub(l.metrics.runningTime)
+			} else {
+				m.RunDuration = time.Since(l.metrics.runningTime)
+			}
+		}
+		return m
+	})
+}
+
+func childrenMetrics(self Lifecycle, children []TreeChild) Metrics {
+	m := GetMetrics(self)
+	for _, child := range children {
+		if m.Children == nil {
+			m.Children = make(map[string]Metrics)
+		}
+		m.Children[child.Name] = GetMetrics(child.Lifecycle)
+	}
+	return m
+}
+
+func (g *Group) Metrics() Metrics {
+	return childrenMetrics(g.lifecycle, g.TreeChildren())
+}
+
+func (l *List) Metrics() Metrics {
+	return childrenMetrics(l.lifecycle, l.TreeChildren())
+}
+
+func (g *Graph) Metrics() Metrics {
+	return childrenMetrics(g.lifecycle, g.TreeChildren())
+}
+
+func (s *Supervisor) Metrics() Metrics {
+	m := childrenMetrics(s.lifecycle, s.TreeChildren())
+	for _, child := range s.getChildren() {
+		cm := m.Children[child.name]
+		cm.Restarts = child.Restarts()
+		m.Children[child.name] = cm
+	}
+	return m
+}
+
+func (r *Retryable[LIFECYCLE]) Metrics() Metrics {
+	m := childrenMetrics(r.Lifecycle, r.TreeChildren())
+	m.Restarts = r.restarts.Load()
+	return m
+}
+
+func (p *HealthProber[LIFECYCLE]) Metrics() Metrics {
+	return childrenMetrics(p.Lifecycle, p.TreeChildren())
+}
+
+// MetricsRegistry 记录了需要导出统计信息的生命周期组件，可以将所有组件及其子组件的统计
+// 信息以 Prometheus 文本格式导出
+type MetricsRegistry struct {
+	lifecycles map[string]Lifecycle
+	mu         sync.Mutex
+}
+
+func NewMetricsRegistry() *MetricsRegistry {
+	return &MetricsRegistry{lifecycles: make(map[string]Lifecycle)}
+}
+
+var DefaultMetricsRegistry = NewMetricsRegistry()
+
+func (r *MetricsRegistry) Register(name string, lifecycle Lifecycle) {
+	lock.LockDo(&r.mu, func() { r.lifecycles[name] = lifecycle })
+}
+
+func (r *MetricsRegistry) Unregister(name string) {
+	lock.LockDo(&r.mu, func() { delete(r.lifecycles, name) })
+}
+
+// Gather 获取所有注册的组件及其子组件的统计信息，子组件的名称为父组件与子组件的名称
+// 使用'/'连接
+func (r *MetricsRegistry) Gather() map[string]Metrics {
+	lifecycles := lock.LockGet(&r.mu, func() map[string]Lifecycle {
+		lifecycles := make(map[string]Lifecycle, len(r.lifecycles))
+		for name, lifecycle := range r.lifecycles {
+			lifecycles[name] = lifecycle
+		}
+		return lifecycles
+	})
+	gathered := make(map[string]Metrics)
+	var flatten func(name string, m Metrics)
+	flatten = func(name string, m Metrics) {
+		for childName, child := range m.Children {
+			flatten(name+"/"+childName, child)
+		}
+		m.Children = nil
+		gathered[name] = m
+	}
+	for name, lifecycle := range lifecycles {
+		flatten(name, GetMetrics(lifecycle))
+	}
+	return gathered
+}
+
+type metricFamily struct {
+	name  string
+	help  string
+	typ   string
+	value func(m Metrics) float64
+}
+
+var metricFamilies = []metricFamily{
+	{"lifecycle_state", "Current state of the lifecycle (0 closed, 1 starting, 2 running, 3 closing, 4 pausing, 5 paused, 6 resuming).", "gauge",
+		func(m Metrics) float64 { return float64(m.State) }},
+	{"lifecycle_start_duration_seconds", "Duration of the last start of the lifecycle.", "gauge",
+		func(m Metrics) float64 { return m.StartDuration.Seconds() }},
+	{"lifecycle_run_duration_seconds", "Duration of the current or last run of the lifecycle.", "gauge",
+		func(m Metrics) float64 { return m.RunDuration.Seconds() }},
+	{"lifecycle_close_duration_seconds", "Duration of the last close of the lifecycle.", "gauge",
+		func(m Metrics) float64 { return m.CloseDuration.Seconds() }},
+	{"lifecycle_starts_total", "Total number of starts of the lifecycle.", "counter",
+		func(m Metrics) float64 { return float64(m.Starts) }},
+	{"lifecycle_start_errors_total", "Total number of failed starts of the lifecycle.", "counter",
+		func(m Metrics) float64 { return float64(m.StartErrors) }},
+	{"lifecycle_exit_errors_total", "Total number of exits with error of the lifecycle.", "counter",
+		func(m Metrics) float64 { return float64(m.ExitErrors) }},
+	{"lifecycle_restarts_total", "Total number of restarts of the lifecycle.", "counter",
+		func(m Metrics) float64 { return float64(m.Restarts) }},
+}
+
+var labelValueReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+// WritePrometheus 将所有注册的组件及其子组件的统计信息以 Prometheus 文本格式写入
+func (r *MetricsRegistry) WritePrometheus(w io.Writer) error {
+	gathered := r.Gather()
+	names := make([]string, 0, len(gathered))
+	for name := range gathered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	bw := bufio.NewWriter(w)
+	for _, family := range metricFamilies {
+		bw.WriteString("# HELP " + family.name + " " + family.help + "\n")
+		bw.WriteString("# TYPE " + family.name + " " + family.typ + "\n")
+		for _, name := range names {
+			bw.WriteString(family.name + `{lifecycle="` + labelValueReplacer.Replace(name) + `"} `)
+			bw.WriteString(strconv.FormatFloat(family.value(gathered[name]), 'g', -1, 64))
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+func (r *MetricsRegistry) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WritePrometheus(w)
+}
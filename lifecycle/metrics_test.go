@@ -0,0 +1,86 @@
+package lifecycle
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMetrics(t *testing.T) {
+	var starts int
+	flaky := NewWithInterruptedRun(func(Lifecycle, chan struct{}) error {
+		time.Sleep(time.Millisecond * 20)
+		if starts++; starts == 1 {
+			return errors.New("start error")
+		}
+		return nil
+	}, InterrupterHoldRun)
+	retryable := NewRetryable(flaky).SetLazyStart(true).SetRetryInterval(time.Millisecond * 10)
+	g := NewGroup()
+	g.MustAdd("db", NewWithInterruptedRun(nil, InterrupterHoldRun))
+	g.MustAdd("conn", retryable)
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	for !flaky.LoadState().Running() {
+		time.Sleep(time.Millisecond)
+	}
+	if err := g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+
+	m := g.Metrics()
+	if m.Starts != 1 || len(m.Children) != 2 || m.RunDuration <= 0 {
+		t.Fatalf("unexpected group metrics %+v", m)
+	}
+	conn := m.Children["conn"]
+	if conn.Restarts != 1 {
+		t.Errorf("expect 1 restart, got %d", conn.Restarts)
+	}
+	if fm := conn.Children["lifecycle"]; fm.Starts != 2 || fm.StartErrors != 1 || fm.StartDuration < time.Millisecond*20 {
+		t.Errorf("unexpected child metrics %+v", fm)
+	}
+
+	registry := NewMetricsRegistry()
+	registry.Register("app", g)
+	buf := bytes.Buffer{}
+	if err := registry.WritePrometheus(&buf); err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		"# TYPE lifecycle_restarts_total counter\n",
+		`lifecycle_restarts_total{lifecycle="app/conn"} 1` + "\n",
+		`lifecycle_start_errors_total{lifecycle="app/conn/lifecycle"} 1` + "\n",
+		`lifecycle_starts_total{lifecycle="app/db"} 1` + "\n",
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("expect %q in output:\n%s", s, buf.String())
+		}
+	}
+}
+
+func TestMetricsExitErrors(t *testing.T) {
+	// 运行中退出和被关闭后退出返回的错误都计为退出错误
+	var closing bool
+	l := NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+		if !closing {
+			return errors.New("exit error")
+		}
+		<-interrupter
+		return errors.New("close error")
+	})
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	<-l.ClosedWaiter()
+	closing = true
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	l.Shutdown()
+	if m := GetMetrics(l); m.Starts != 2 || m.ExitErrors != 2 {
+		t.Errorf("expect 2 exit errors, got %+v", m)
+	}
+}
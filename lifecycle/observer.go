@@ -0,0 +1,181 @@
+package lifecycle
+
+import (
+	"gitee.com/sy_183/common/lock"
+	"sync"
+	"time"
+)
+
+const NameFieldName = "$name"
+
+// StateEvent 为生命周期组件的状态切换事件
+type StateEvent struct {
+	Lifecycle Lifecycle
+	Name      string
+	From      State
+	To        State
+	Err       error
+	Time      time.Time
+}
+
+// Observer 用于观察生命周期组件的状态切换，同一个组件的事件按照切换的顺序依次通知，观察
+// 者不应该在通知中阻塞过长的时间
+type Observer interface {
+	OnStateChanged(event StateEvent)
+}
+
+type ObserverFunc func(event StateEvent)
+
+func (f ObserverFunc) OnStateChanged(event StateEvent) {
+	f(event)
+}
+
+func WithName(name string) Option {
+	return optionFunc(func(lifecycle Lifecycle) {
+		lifecycle.SetField(NameFieldName, name)
+	})
+}
+
+// GetName 获取生命周期组件的名称，组件的名称通过 WithName 指定，或者在添加到生命周期组、
+// 图或监督者时使用添加时指定的名称
+func GetName(lifecycle Lifecycle) string {
+	if name, is := lifecycle.Field(NameFieldName).(string); is {
+		return name
+	}
+	return ""
+}
+
+type observerEntry struct {
+	id       uint64
+	observer Observer
+}
+
+type observers struct {
+	entries []observerEntry
+	nextId  uint64
+	mu      sync.Mutex
+}
+
+func (o *observers) add(observer Observer) (cancel func()) {
+	id := lock.LockGet(&o.mu, func() uint64 {
+		o.nextId++
+		o.entries = append(o.entries, observerEntry{id: o.nextId, observer: observer})
+		return o.nextId
+	})
+	return func() {
+		lock.LockDo(&o.mu, func() {
+			for i, entry := range o.entries {
+				if entry.id == id {
+					o.entries = append(o.entries[:i:i], o.entries[i+1:]...)
+					return
+				}
+			}
+		})
+	}
+}
+
+func (o *observers) notify(event StateEvent) {
+	entries := lock.LockGet(&o.mu, func() []observerEntry { return o.entries })
+	for _, entry := range entries {
+		entry.observer.OnStateChanged(event)
+	}
+}
+
+var globalObservers observers
+
+// ObserveAll 添加一个观察所有生命周期组件状态切换的观察者，返回的函数用于取消观察
+func ObserveAll(observer Observer) (cancel func()) {
+	return globalObservers.add(observer)
+}
+
+// Observable 由可以观察状态切换的生命周期组件实现
+type Observable interface {
+	Observe(observer Observer) (cancel func())
+}
+
+// Observe 添加观察组件状态切换的观察者，返回的函数用于取消观察，组件没有实现 Observable
+// 时观察者不会收到任何通知
+func Observe(lifecycle Lifecycle, observer Observer) (cancel func()) {
+	if observable, ok := lookup[Observable](lifecycle); ok {
+		return observable.Observe(observer)
+	}
+	return func() {}
+}
+
+// Observe 添加观察组件状态切换的观察者，返回的函数用于取消观察
+func (l *DefaultLifecycle) Observe(observer Observer) (cancel func()) {
+	return l.observers.add(observer)
+}
+
+// switchState 切换组件的状态并记录状态切换事件，调用时必须持有组件的锁，事件在调用
+// notifyEvents 后通知给观察者
+func (l *DefaultLifecycle) switchState(to State, err error) {
+	from := l.State
+	switch to {
+	case StateClosed:
+		l.State.ToClosed()
+	case StateStarting:
+		l.State.ToStarting()
+	case StateRunning:
+		l.State.ToRunning()
+	case StateClosing:
+		l.State.ToClosing()
+	case StatePausing:
+		l.State.ToPausing()
+	case StatePaused:
+		l.State.ToPaused()
+	case StateResuming:
+		l.State.ToResuming()
+	}
+	if from != to {
+		now := time.Now()
+		switch to {
+		case StateRunning:
+			l.runningTime.Store(now.UnixNano())
+		case StateClosed:
+			l.runningTime.Store(0)
+		}
+		l.metrics.record(from, to, err, now)
+		l.events = append(l.events, StateEvent{From: from, To: to, Err: err, Time: now})
+	}
+}
+
+// ToClosing 将组件的状态切换为关闭中，调用时必须持有组件的锁
+func (l *DefaultLifecycle) ToClosing() {
+	l.switchState(StateClosing, nil)
+}
+
+// notifyEvents 将记录的状态切换事件按照顺序通知给组件的观察者和全局的观察者，调用时
+// 不能持有组件的锁。通知观察者时不持有任何锁，观察者可以在通知中关闭组件。同一时间只有
+// 一个协程通知事件，其他协程(包括在通知中切换组件状态的观察者)记录的事件由正在通知的协
+// 程按照顺序继续通知，以保证事件的顺序，所以观察者收到事件时组件的状态可能已经再次切换
+func (l *DefaultLifecycle) notifyEvents() {
+	events := lock.LockGet(l, func() []StateEvent {
+		if l.notifying {
+			return nil
+		}
+		return l.takeEvents()
+	})
+	if len(events) == 0 {
+		return
+	}
+	self := l.self()
+	name := GetName(self)
+	for len(events) > 0 {
+		for _, event := range events {
+			event.Lifecycle, event.Name = self, name
+			l.observers.notify(event)
+			globalObservers.notify(event)
+		}
+		events = lock.LockGet(l, l.takeEvents)
+	}
+}
+
+// takeEvents 取出记录的状态切换事件，并根据是否有事件设置组件是否正在通知事件，调用时
+// 必须持有组件的锁
+func (l *DefaultLifecycle) takeEvents() []StateEvent {
+	events := l.events
+	l.events = nil
+	l.notifying = len(events) > 0
+	return events
+}
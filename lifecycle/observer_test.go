@@ -0,0 +1,110 @@
+package lifecycle
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestObserve(t *testing.T) {
+	var events []StateEvent
+	var mu sync.Mutex
+	observer := ObserverFunc(func(event StateEvent) {
+		mu.Lock()
+		events = append(events, event)
+		mu.Unlock()
+	})
+	startErr := errors.New("start error")
+	var fail bool
+	l := NewWithInterruptedRun(func(Lifecycle, chan struct{}) error {
+		if fail {
+			return startErr
+		}
+		return nil
+	}, InterrupterHoldRun, WithName("test"))
+	cancel := l.Observe(observer)
+	var global int
+	cancelGlobal := ObserveAll(ObserverFunc(func(event StateEvent) {
+		if event.Lifecycle == Lifecycle(l) {
+			global++
+		}
+	}))
+	defer cancelGlobal()
+
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := l.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	fail = true
+	if err := l.Start(); err != startErr {
+		t.Fatalf("expect start error, got %v", err)
+	}
+
+	expected := [][2]State{
+		{StateClosed, StateStarting},
+		{StateStarting, StateRunning},
+		{StateRunning, StateClosing},
+		{StateClosing, StateClosed},
+		{StateClosed, StateStarting},
+		{StateStarting, StateClosed},
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expect %d events, got %d", len(expected), len(events))
+	}
+	for i, event := range events {
+		if event.From != expected[i][0] || event.To != expected[i][1] || event.Name != "test" {
+			t.Errorf("unexpected event %d: %s %s -> %s", i, event.Name, event.From, event.To)
+		}
+	}
+	if events[5].Err != startErr {
+		t.Error("expect start error in event")
+	}
+	if global != len(expected) {
+		t.Errorf("expect %d global events, got %d", len(expected), global)
+	}
+
+	cancel()
+	fail = false
+	l.Start()
+	l.Shutdown()
+	if len(events) != len(expected) {
+		t.Error("observer should not be notified after cancel")
+	}
+}
+
+func TestObserverClose(t *testing.T) {
+	var states []State
+	closed := make(chan struct{})
+	l := NewWithInterruptedRun(nil, InterrupterHoldRun)
+	l.Observe(ObserverFunc(func(event StateEvent) {
+		states = append(states, event.To)
+		switch event.To {
+		case StateRunning:
+			// 观察者在通知中关闭组件不能导致死锁
+			event.Lifecycle.Close(nil)
+		case StateClosed:
+			close(closed)
+		}
+	}))
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("lifecycle closed by observer should not deadlock")
+	}
+	expected := []State{StateStarting, StateRunning, StateClosing, StateClosed}
+	if len(states) != len(expected) {
+		t.Fatalf("expect states %v, got %v", expected, states)
+	}
+	for i, state := range states {
+		if state != expected[i] {
+			t.Errorf("expect states %v, got %v", expected, states)
+			break
+		}
+	}
+}
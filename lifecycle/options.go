@@ -23,14 +23,27 @@ func WithRunner(runner Runner) Option {
 		if setter, is := lifecycle.(interface{ setRunner(runner Runner) }); is {
 			setter.setRunner(runner)
 		}
+		setPauserIfPausable(lifecycle, runner)
 	})
 }
 
 func WithInterruptedRunner(runner InterruptedRunner) Option {
 	return optionFunc(func(lifecycle Lifecycle) {
 		if canInterrupted, is := lifecycle.(canInterrupted); is {
-			canInterrupted.setRunner(newInterrupterRunner(canInterrupted, runner))
+			r := newInterrupterRunner(canInterrupted, runner)
+			canInterrupted.setRunner(r)
+			setEscalator(lifecycle, &r.escalator)
 		}
+		setPauserIfPausable(lifecycle, runner)
+	})
+}
+
+func WithContextRunner(runner ContextRunner) Option {
+	return optionFunc(func(lifecycle Lifecycle) {
+		if canInterrupted, is := lifecycle.(canInterrupted); is {
+			canInterrupted.setRunner(newContextRunner(canInterrupted, runner))
+		}
+		setPauserIfPausable(lifecycle, runner)
 	})
 }
 
@@ -39,13 +52,38 @@ func WithStarter(starter Starter) Option {
 		if setter, is := lifecycle.(interface{ setRunner(runner Runner) }); is {
 			setter.setRunner(newStarterRunner(setter, starter).Runner())
 		}
+		setPauserIfPausable(lifecycle, starter)
 	})
 }
 
 func WithInterruptedStarter(starter InterruptedStarter) Option {
 	return optionFunc(func(lifecycle Lifecycle) {
 		if canInterrupted, is := lifecycle.(canInterrupted); is {
-			canInterrupted.setRunner(newInterruptedStarter(canInterrupted, starter).Runner())
+			s := newInterruptedStarter(canInterrupted, starter)
+			canInterrupted.setRunner(s.Runner())
+			setEscalator(lifecycle, &s.escalator)
 		}
+		setPauserIfPausable(lifecycle, starter)
 	})
 }
+
+// WithPauser 指定组件暂停和恢复的方式，如果组件的运行器实现了 Pausable，则默认使用运行器
+func WithPauser(pauser Pausable) Option {
+	return optionFunc(func(lifecycle Lifecycle) {
+		if setter, is := lifecycle.(interface{ setPauser(pauser Pausable) }); is {
+			setter.setPauser(pauser)
+		}
+	})
+}
+
+func setEscalator(lifecycle Lifecycle, escalator *interruptEscalator) {
+	if setter, is := lifecycle.(interface{ setEscalator(*interruptEscalator) }); is {
+		setter.setEscalator(escalator)
+	}
+}
+
+func setPauserIfPausable(lifecycle Lifecycle, runner any) {
+	if pauser, is := runner.(Pausable); is {
+		WithPauser(pauser).Apply(lifecycle)
+	}
+}
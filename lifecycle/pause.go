@@ -0,0 +1,202 @@
+package lifecycle
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/lock"
+)
+
+// Pausable 由支持暂停的组件实现，暂停的组件不再处理新的工作，但不释放已经持有的资源，
+// 例如网络连接。暂停中的组件仍然需要响应关闭
+type Pausable interface {
+	DoPause(lifecycle Lifecycle) error
+
+	DoResume(lifecycle Lifecycle) error
+}
+
+type (
+	PauseFunc  = func(lifecycle Lifecycle) error
+	ResumeFunc = func(lifecycle Lifecycle) error
+)
+
+type pauserFunc struct {
+	pauseFn  PauseFunc
+	resumeFn ResumeFunc
+}
+
+func FuncPauser(pauseFn PauseFunc, resumeFn ResumeFunc) Pausable {
+	if pauseFn == nil {
+		pauseFn = func(Lifecycle) error { return nil }
+	}
+	if resumeFn == nil {
+		resumeFn = func(Lifecycle) error { return nil }
+	}
+	return pauserFunc{pauseFn: pauseFn, resumeFn: resumeFn}
+}
+
+func (f pauserFunc) DoPause(lifecycle Lifecycle) error {
+	return f.pauseFn(lifecycle)
+}
+
+func (f pauserFunc) DoResume(lifecycle Lifecycle) error {
+	return f.resumeFn(lifecycle)
+}
+
+func (l *DefaultLifecycle) setPauser(pauser Pausable) {
+	l.pauser = pauser
+}
+
+// PauseResumer 由可以暂停和恢复的生命周期组件实现
+type PauseResumer interface {
+	Pause() error
+
+	Resume() error
+}
+
+// Pause 暂停组件，组件没有实现 PauseResumer 时返回 PauseNotSupportedError
+func Pause(lifecycle Lifecycle) error {
+	if pauser, ok := lookup[PauseResumer](lifecycle); ok {
+		return pauser.Pause()
+	}
+	return NewPauseNotSupportedError("")
+}
+
+// Resume 恢复已暂停的组件，组件没有实现 PauseResumer 时返回 PauseNotSupportedError
+func Resume(lifecycle Lifecycle) error {
+	if pauser, ok := lookup[PauseResumer](lifecycle); ok {
+		return pauser.Resume()
+	}
+	return NewPauseNotSupportedError("")
+}
+
+// Pause 暂停运行中的组件，暂停过程中组件的状态为 PAUSING，暂停完成后为 PAUSED，如果暂
+// 停失败，组件恢复为 RUNNING 状态。如果组件不支持暂停，返回 PauseNotSupportedError
+func (l *DefaultLifecycle) Pause() error {
+	pauser, err := lock.LockGetDouble(l, func() (Pausable, error) {
+		if l.pauser == nil {
+			return nil, NewPauseNotSupportedError("")
+		}
+		if !l.Running() {
+			return nil, NewStateNotAllowSwitchError("", l.State.String(), StatePausing.String())
+		}
+		l.switchState(StatePausing, nil)
+		return l.pauser, nil
+	})
+	if err != nil {
+		return err
+	}
+	l.notifyEvents()
+	err = pauser.DoPause(l.self())
+	lock.LockDo(l, func() {
+		// 暂停期间组件可能已经开始关闭
+		if l.Pausing() {
+			if err != nil {
+				l.switchState(StateRunning, err)
+			} else {
+				l.switchState(StatePaused, nil)
+			}
+		}
+	})
+	l.notifyEvents()
+	return err
+}
+
+// Resume 恢复已暂停的组件，恢复过程中组件的状态为 RESUMING，恢复完成后为 RUNNING，如果
+// 恢复失败，组件仍然为 PAUSED 状态
+func (l *DefaultLifecycle) Resume() error {
+	pauser, err := lock.LockGetDouble(l, func() (Pausable, error) {
+		if l.pauser == nil {
+			return nil, NewPauseNotSupportedError("")
+		}
+		if !l.Paused() {
+			return nil, NewStateNotAllowSwitchError("", l.State.String(), StateResuming.String())
+		}
+		l.switchState(StateResuming, nil)
+		return l.pauser, nil
+	})
+	if err != nil {
+		return err
+	}
+	l.notifyEvents()
+	err = pauser.DoResume(l.self())
+	lock.LockDo(l, func() {
+		if l.Resuming() {
+			if err != nil {
+				l.switchState(StatePaused, err)
+			} else {
+				l.switchState(StateRunning, nil)
+			}
+		}
+	})
+	l.notifyEvents()
+	return err
+}
+
+// skipPauseError 判断暂停或恢复子组件时返回的错误是否可以忽略，不支持暂停或者状态不允
+// 许暂停的子组件不影响父组件的暂停和恢复
+func skipPauseError(err error) bool {
+	var notSupported *PauseNotSupportedError
+	var notAllowSwitch *StateNotAllowSwitchError
+	return errors.As(err, &notSupported) || errors.As(err, &notAllowSwitch)
+}
+
+// pauseChildren 按照逆序暂停子组件，如果某个子组件暂停失败，则恢复已经暂停的子组件并返
+// 回错误
+func pauseChildren(children []TreeChild) error {
+	var paused []Lifecycle
+	for i := len(children) - 1; i >= 0; i-- {
+		child := children[i].Lifecycle
+		if err := Pause(child); err != nil {
+			if skipPauseError(err) {
+				continue
+			}
+			for j := len(paused) - 1; j >= 0; j-- {
+				Resume(paused[j])
+			}
+			return err
+		}
+		paused = append(paused, child)
+	}
+	return nil
+}
+
+// resumeChildren 按照顺序恢复子组件，某个子组件恢复失败不影响其他子组件的恢复
+func resumeChildren(children []TreeChild) (err error) {
+	for _, child := range children {
+		if e := Resume(child.Lifecycle); e != nil && !skipPauseError(e) {
+			err = errors.Append(err, e)
+		}
+	}
+	return
+}
+
+func (g *Group) pause(Lifecycle) error {
+	return pauseChildren(g.TreeChildren())
+}
+
+func (g *Group) resume(Lifecycle) error {
+	return resumeChildren(g.TreeChildren())
+}
+
+func (l *List) pause(Lifecycle) error {
+	return pauseChildren(l.TreeChildren())
+}
+
+func (l *List) resume(Lifecycle) error {
+	return resumeChildren(l.TreeChildren())
+}
+
+func (g *Graph) pause(Lifecycle) error {
+	return pauseChildren(g.TreeChildren())
+}
+
+func (g *Graph) resume(Lifecycle) error {
+	return resumeChildren(g.TreeChildren())
+}
+
+func (s *Supervisor) pause(Lifecycle) error {
+	return pauseChildren(s.TreeChildren())
+}
+
+func (s *Supervisor) resume(Lifecycle) error {
+	return resumeChildren(s.TreeChildren())
+}
@@ -0,0 +1,98 @@
+package lifecycle
+
+import (
+	"errors"
+	"sync/atomic"
+	"testing"
+)
+
+func TestPause(t *testing.T) {
+	var paused atomic.Bool
+	newPausable := func() *DefaultLifecycle {
+		return NewWithInterruptedRun(nil, InterrupterHoldRun, WithPauser(FuncPauser(func(Lifecycle) error {
+			paused.Store(true)
+			return nil
+		}, func(Lifecycle) error {
+			paused.Store(false)
+			return nil
+		})))
+	}
+	child := newPausable()
+	list := NewList()
+	list.MustAppend(child)
+	list.MustAppend(NewWithInterruptedRun(nil, InterrupterHoldRun))
+	g := NewGroup()
+	g.MustAdd("list", list)
+
+	if err := Pause(g); err == nil {
+		t.Error("closed group should not be paused")
+	} else if _, is := err.(*StateNotAllowSwitchError); !is {
+		t.Errorf("unexpected error type %T", err)
+	}
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+
+	if err := Pause(g); err != nil {
+		t.Fatal(err)
+	}
+	if !GetState(g).Paused() || !GetState(list).Paused() || !child.LoadState().Paused() || !paused.Load() {
+		t.Fatalf("group and children should be paused")
+	}
+	if err := Pause(g); err == nil {
+		t.Error("paused group should not be paused again")
+	}
+	if err := Resume(g); err != nil {
+		t.Fatal(err)
+	}
+	if !GetState(g).Running() || !child.LoadState().Running() || paused.Load() {
+		t.Error("group and children should be running")
+	}
+
+	var notSupported *PauseNotSupportedError
+	if err := New().Pause(); !errors.As(err, &notSupported) {
+		t.Errorf("expect pause not supported error, got %v", err)
+	}
+}
+
+func TestPauseFailed(t *testing.T) {
+	pauseErr := errors.New("pause error")
+	var resumed atomic.Bool
+	g := NewGroup()
+	// 子组件按照逆序暂停，b暂停成功后a暂停失败，b需要被恢复
+	g.MustAdd("a", NewWithInterruptedRun(nil, InterrupterHoldRun, WithPauser(FuncPauser(func(Lifecycle) error {
+		return pauseErr
+	}, nil))))
+	g.MustAdd("b", NewWithInterruptedRun(nil, InterrupterHoldRun, WithPauser(FuncPauser(nil, func(Lifecycle) error {
+		resumed.Store(true)
+		return nil
+	}))))
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer g.Shutdown()
+	if err := Pause(g); err != pauseErr {
+		t.Fatalf("expect pause error, got %v", err)
+	}
+	if !GetState(g).Running() || !resumed.Load() {
+		t.Error("group should be running and paused children should be resumed")
+	}
+}
+
+func TestPauseClose(t *testing.T) {
+	g := NewGroup()
+	g.MustAdd("a", NewWithInterruptedRun(nil, InterrupterHoldRun, WithPauser(FuncPauser(nil, nil))))
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := Pause(g); err != nil {
+		t.Fatal(err)
+	}
+	if err := g.Shutdown(); err != nil {
+		t.Fatal(err)
+	}
+	if !GetState(g).Closed() {
+		t.Error("paused group should be closed")
+	}
+}
@@ -0,0 +1,163 @@
+package retry
+
+import (
+	"math"
+	"math/rand"
+	"time"
+)
+
+// Backoff 为重试的退避策略，retry 为即将进行的重试的次数，从1开始，last 为上一次重试的
+// 等待时间，第一次重试时为0
+type Backoff interface {
+	Next(retry int, last time.Duration) time.Duration
+}
+
+type BackoffFunc func(retry int, last time.Duration) time.Duration
+
+func (f BackoffFunc) Next(retry int, last time.Duration) time.Duration {
+	return f(retry, last)
+}
+
+func capDuration(d, max time.Duration) time.Duration {
+	if max > 0 && d > max {
+		return max
+	}
+	return d
+}
+
+// randomBetween 返回 [min, max] 范围内的随机时间
+func randomBetween(min, max time.Duration) time.Duration {
+	if max <= min {
+		return min
+	}
+	n := int64(max - min)
+	if n < math.MaxInt64 {
+		n++
+	}
+	return min + time.Duration(rand.Int63n(n))
+}
+
+// exponential 计算 initial * multiplier^(retry-1)，溢出时返回 math.MaxInt64
+func exponential(initial time.Duration, multiplier float64, retry int) time.Duration {
+	d := float64(initial) * math.Pow(multiplier, float64(retry-1))
+	if d >= math.MaxInt64 || math.IsInf(d, 0) || math.IsNaN(d) {
+		return math.MaxInt64
+	}
+	return time.Duration(d)
+}
+
+type constantBackoff time.Duration
+
+// ConstantBackoff 每次重试的等待时间固定为 interval
+func ConstantBackoff(interval time.Duration) Backoff {
+	return constantBackoff(interval)
+}
+
+func (b constantBackoff) Next(int, time.Duration) time.Duration {
+	return time.Duration(b)
+}
+
+type linearBackoff struct {
+	initial, step, max time.Duration
+}
+
+// LinearBackoff 第n次重试的等待时间为 initial + step * (n-1)，且不超过 max，max 小于等于0
+// 时不限制
+func LinearBackoff(initial, step, max time.Duration) Backoff {
+	return linearBackoff{initial: initial, step: step, max: max}
+}
+
+func (b linearBackoff) Next(retry int, _ time.Duration) time.Duration {
+	d := b.initial
+	if b.step > 0 && retry > 1 {
+		if n := time.Duration(retry - 1); n > (math.MaxInt64-d)/b.step {
+			d = math.MaxInt64
+		} else {
+			d += b.step * n
+		}
+	}
+	return capDuration(d, b.max)
+}
+
+type exponentialBackoff struct {
+	initial    time.Duration
+	multiplier float64
+	max        time.Duration
+}
+
+// ExponentialBackoff 第n次重试的等待时间为 initial * multiplier^(n-1)，且不超过 max，max
+// 小于等于0时不限制。multiplier 小于等于1时使用2
+func ExponentialBackoff(initial time.Duration, multiplier float64, max time.Duration) Backoff {
+	if multiplier <= 1 {
+		multiplier = 2
+	}
+	return exponentialBackoff{initial: initial, multiplier: multiplier, max: max}
+}
+
+func (b exponentialBackoff) Next(retry int, _ time.Duration) time.Duration {
+	return capDuration(exponential(b.initial, b.multiplier, retry), b.max)
+}
+
+type fullJitterBackoff struct {
+	base, max time.Duration
+}
+
+// FullJitterBackoff 第n次重试的等待时间为 [0, min(max, base * 2^(n-1))] 范围内的随机值，
+// 用于避免大量客户端同时重试
+func FullJitterBackoff(base, max time.Duration) Backoff {
+	return fullJitterBackoff{base: base, max: max}
+}
+
+func (b fullJitterBackoff) Next(retry int, _ time.Duration) time.Duration {
+	return randomBetween(0, capDuration(exponential(b.base, 2, retry), b.max))
+}
+
+type decorrelatedJitterBackoff struct {
+	base, max time.Duration
+}
+
+// DecorrelatedJitterBackoff 每次重试的等待时间为 [base, last * 3] 范围内的随机值，且不超过
+// max，上一次重试的等待时间小于 base 时按照 base 计算
+func DecorrelatedJitterBackoff(base, max time.Duration) Backoff {
+	return decorrelatedJitterBackoff{base: base, max: max}
+}
+
+func (b decorrelatedJitterBackoff) Next(_ int, last time.Duration) time.Duration {
+	if last < b.base {
+		last = b.base
+	}
+	upper := last * 3
+	if upper/3 != last {
+		upper = math.MaxInt64
+	}
+	return capDuration(randomBetween(b.base, upper), b.max)
+}
+
+type jitterBackoff struct {
+	backoff Backoff
+	jitter  float64
+}
+
+// JitterBackoff 在退避策略的等待时间上添加随机抖动，等待时间为 [d * (1-jitter), d * (1+jitter)]
+// 范围内的随机值，jitter 的取值范围为[0, 1]
+func JitterBackoff(backoff Backoff, jitter float64) Backoff {
+	if jitter < 0 {
+		jitter = 0
+	} else if jitter > 1 {
+		jitter = 1
+	}
+	return jitterBackoff{backoff: backoff, jitter: jitter}
+}
+
+func (b jitterBackoff) Next(retry int, last time.Duration) time.Duration {
+	d := b.backoff.Next(retry, last)
+	if b.jitter == 0 || d <= 0 {
+		return d
+	}
+	delta := time.Duration(float64(d) * b.jitter)
+	upper := d + delta
+	if upper < d {
+		upper = math.MaxInt64
+	}
+	return randomBetween(d-delta, upper)
+}
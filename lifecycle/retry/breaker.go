@@ -0,0 +1,306 @@
+package retry
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+type BreakerState int32
+
+const (
+	BreakerClosed BreakerState = iota
+	BreakerOpen
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "CLOSED"
+	case BreakerOpen:
+		return "OPEN"
+	case BreakerHalfOpen:
+		return "HALF_OPEN"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+const (
+	DefaultBreakerWindow   = time.Second * 10
+	DefaultBreakerCoolDown = time.Second * 5
+
+	breakerBuckets = 10
+)
+
+// BreakerEvent 为熔断器的状态切换事件，Err 为导致熔断器打开的错误
+type BreakerEvent struct {
+	Breaker *CircuitBreaker
+	Name    string
+	From    BreakerState
+	To      BreakerState
+	Err     error
+	Time    time.Time
+}
+
+// BreakerOpenError 为熔断器打开时拒绝执行返回的错误，RetryAfter 为熔断器进入半开状态
+// 前剩余的时间，半开状态下试探请求已满时为试探请求超时前剩余的时间
+type BreakerOpenError struct {
+	Name       string
+	RetryAfter time.Duration
+}
+
+func (e *BreakerOpenError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	if e.Name == "" {
+		return fmt.Sprintf("熔断器已打开，%s后重试", e.RetryAfter)
+	}
+	return fmt.Sprintf("熔断器(%s)已打开，%s后重试", e.Name, e.RetryAfter)
+}
+
+// Breaker 为熔断器的配置
+type Breaker struct {
+	Name string
+
+	// Window 为统计失败率的滑动窗口的长度，默认为 DefaultBreakerWindow
+	Window time.Duration
+
+	// FailureRate 为打开熔断器的失败率阈值，取值范围为(0, 1]，为0时不使用失败率
+	FailureRate float64
+
+	// MinRequests 为使用失败率判断前滑动窗口中最少的请求数量
+	MinRequests int
+
+	// ConsecutiveFailures 为打开熔断器的连续失败次数阈值，为0时不使用连续失败次数
+	ConsecutiveFailures int
+
+	// CoolDown 为熔断器打开后进入半开状态的等待时间，默认为 DefaultBreakerCoolDown。半开
+	// 状态下经过此时间后仍有试探请求没有完成时，认为试探请求超时，熔断器重新打开
+	CoolDown time.Duration
+
+	// HalfOpenRequests 为半开状态下允许的试探请求数量，全部成功后熔断器关闭，默认为1
+	HalfOpenRequests int
+
+	// IsFailure 判断执行的错误是否计为失败，默认除 nil 和 InterruptedError 以外的错误都
+	// 计为失败
+	IsFailure func(err error) bool
+
+	// OnStateChange 在熔断器状态切换后调用
+	OnStateChange func(event BreakerEvent)
+}
+
+type breakerBucket struct {
+	index     int64
+	successes int
+	failures  int
+}
+
+// CircuitBreaker 为熔断器，熔断器关闭时正常执行，当滑动窗口中的失败率或连续失败次数达到
+// 阈值时打开，打开后直接拒绝执行，经过冷却时间后进入半开状态，允许少量的试探请求，试探请
+// 求全部成功后关闭，任意一个试探请求失败则重新打开
+type CircuitBreaker struct {
+	config Breaker
+
+	state       BreakerState
+	generation  uint64
+	changedAt   time.Time
+	consecutive int
+	buckets     [breakerBuckets]breakerBucket
+	trials      int
+	trialPassed int
+
+	mu sync.Mutex
+}
+
+func NewCircuitBreaker(config Breaker) *CircuitBreaker {
+	if config.Window <= 0 {
+		config.Window = DefaultBreakerWindow
+	}
+	if config.CoolDown <= 0 {
+		config.CoolDown = DefaultBreakerCoolDown
+	}
+	if config.HalfOpenRequests <= 0 {
+		config.HalfOpenRequests = 1
+	}
+	if config.IsFailure == nil {
+		config.IsFailure = func(err error) bool { return err != nil && err != InterruptedError }
+	}
+	return &CircuitBreaker{config: config}
+}
+
+func (b *CircuitBreaker) Name() string {
+	return b.config.Name
+}
+
+// State 返回熔断器当前的状态，熔断器打开并经过冷却时间后返回半开状态
+func (b *CircuitBreaker) State() BreakerState {
+	b.mu.Lock()
+	event := b.refresh(time.Now())
+	state := b.state
+	b.mu.Unlock()
+	b.notify(event)
+	return state
+}
+
+// switchState 切换熔断器的状态，调用时必须持有熔断器的锁
+func (b *CircuitBreaker) switchState(to BreakerState, err error, now time.Time) *BreakerEvent {
+	from := b.state
+	b.state = to
+	b.generation++
+	b.changedAt = now
+	b.trials, b.trialPassed = 0, 0
+	switch to {
+	case BreakerClosed:
+		b.consecutive = 0
+		b.buckets = [breakerBuckets]breakerBucket{}
+	}
+	return &BreakerEvent{Breaker: b, Name: b.config.Name, From: from, To: to, Err: err, Time: now}
+}
+
+// refresh 在冷却时间结束后将熔断器切换到半开状态，半开状态下试探请求超时后将熔断器重新
+// 打开，调用时必须持有熔断器的锁
+func (b *CircuitBreaker) refresh(now time.Time) *BreakerEvent {
+	if now.Sub(b.changedAt) < b.config.CoolDown {
+		return nil
+	}
+	switch {
+	case b.state == BreakerOpen:
+		return b.switchState(BreakerHalfOpen, nil, now)
+	case b.state == BreakerHalfOpen && b.trials > b.trialPassed:
+		// 试探请求的调用者没有调用 done 或者执行一直没有完成，试探请求的位置不会被释放
+		return b.switchState(BreakerOpen, BreakerTrialTimeoutError, now)
+	}
+	return nil
+}
+
+func (b *CircuitBreaker) notify(event *BreakerEvent) {
+	if event != nil && b.config.OnStateChange != nil {
+		b.config.OnStateChange(*event)
+	}
+}
+
+func (b *CircuitBreaker) bucketSize() int64 {
+	size := int64(b.config.Window) / breakerBuckets
+	if size <= 0 {
+		size = 1
+	}
+	return size
+}
+
+// record 将执行的结果记录到滑动窗口，返回窗口中的成功和失败次数，调用时必须持有熔断器的锁
+func (b *CircuitBreaker) record(failure bool, now time.Time) (successes, failures int) {
+	index := now.UnixNano() / b.bucketSize()
+	bucket := &b.buckets[index%breakerBuckets]
+	if bucket.index != index {
+		*bucket = breakerBucket{index: index}
+	}
+	if failure {
+		bucket.failures++
+	} else {
+		bucket.successes++
+	}
+	for _, bucket := range b.buckets {
+		if bucket.index > index-breakerBuckets {
+			successes += bucket.successes
+			failures += bucket.failures
+		}
+	}
+	return
+}
+
+// Allow 判断是否允许执行，如果熔断器打开或半开状态下试探请求已满，返回 BreakerOpenError。
+// 允许执行时返回的 done 必须在执行完成后使用执行的错误调用，重复调用 done 只有第一次生效，
+// 半开状态下没有调用 done 的试探请求在 CoolDown 后超时，熔断器重新打开
+func (b *CircuitBreaker) Allow() (done func(err error), err error) {
+	generation, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+	var once sync.Once
+	return func(err error) {
+		once.Do(func() { b.done(generation, err, b.config.IsFailure(err)) })
+	}, nil
+}
+
+func (b *CircuitBreaker) allow() (generation uint64, err error) {
+	now := time.Now()
+	b.mu.Lock()
+	event := b.refresh(now)
+	switch b.state {
+	case BreakerOpen:
+		err = &BreakerOpenError{Name: b.config.Name, RetryAfter: b.config.CoolDown - now.Sub(b.changedAt)}
+	case BreakerHalfOpen:
+		if b.trials >= b.config.HalfOpenRequests {
+			err = &BreakerOpenError{Name: b.config.Name, RetryAfter: b.config.CoolDown - now.Sub(b.changedAt)}
+		} else {
+			b.trials++
+		}
+	}
+	generation = b.generation
+	b.mu.Unlock()
+	b.notify(event)
+	return
+}
+
+func (b *CircuitBreaker) done(generation uint64, err error, failure bool) {
+	now := time.Now()
+	b.mu.Lock()
+	var event *BreakerEvent
+	// 状态切换之前允许的执行的结果不再影响熔断器
+	if generation == b.generation {
+		switch b.state {
+		case BreakerClosed:
+			if failure {
+				b.consecutive++
+			} else {
+				b.consecutive = 0
+			}
+			successes, failures := b.record(failure, now)
+			total := successes + failures
+			switch {
+			case !failure:
+			case b.config.ConsecutiveFailures > 0 && b.consecutive >= b.config.ConsecutiveFailures:
+				event = b.switchState(BreakerOpen, err, now)
+			case b.config.FailureRate > 0 && total >= b.config.MinRequests &&
+				float64(failures)/float64(total) >= b.config.FailureRate:
+				event = b.switchState(BreakerOpen, err, now)
+			}
+		case BreakerHalfOpen:
+			if failure {
+				event = b.switchState(BreakerOpen, err, now)
+			} else if b.trialPassed++; b.trialPassed >= b.config.HalfOpenRequests {
+				event = b.switchState(BreakerClosed, nil, now)
+			}
+		}
+	}
+	b.mu.Unlock()
+	b.notify(event)
+}
+
+// Do 通过熔断器执行函数，熔断器打开时直接返回 BreakerOpenError。函数发生 panic 时计为
+// 失败，panic 继续向上传递
+func (b *CircuitBreaker) Do(fn func() error) (err error) {
+	generation, err := b.allow()
+	if err != nil {
+		return err
+	}
+	completed := false
+	defer func() {
+		if completed {
+			b.done(generation, err, b.config.IsFailure(err))
+		} else {
+			b.done(generation, BreakerPanicError, true)
+		}
+	}()
+	err = fn()
+	completed = true
+	return err
+}
+
+// Wrap 返回通过熔断器执行函数的函数
+func (b *CircuitBreaker) Wrap(fn func() error) func() error {
+	return func() error { return b.Do(fn) }
+}
@@ -0,0 +1,41 @@
+package retry
+
+import "gitee.com/sy_183/common/errors"
+
+var (
+	// BreakerTrialTimeoutError 为半开状态下试探请求超时导致熔断器重新打开的错误
+	BreakerTrialTimeoutError = errors.New("熔断器试探请求超时")
+
+	// BreakerPanicError 为通过熔断器执行的函数发生 panic 时记录的错误
+	BreakerPanicError = errors.New("熔断器执行的函数发生 panic")
+)
+
+// PermanentError 标记不可重试的错误，执行返回此错误时不再重试，记录的错误为被包装的错误
+type PermanentError struct {
+	Err error
+}
+
+// Permanent 将错误标记为不可重试的错误，如果错误为nil，返回nil
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &PermanentError{Err: err}
+}
+
+func (e *PermanentError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+	return e.Err
+}
+
+// IsPermanent 判断错误是否被标记为不可重试的错误
+func IsPermanent(err error) bool {
+	var permanentErr *PermanentError
+	return errors.As(err, &permanentErr)
+}
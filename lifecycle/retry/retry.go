@@ -1,7 +1,8 @@
 package retry
 
 import (
-	"errors"
+	"context"
+	"gitee.com/sy_183/common/errors"
 	"gitee.com/sy_183/common/timer"
 	"math"
 	"time"
@@ -16,17 +17,42 @@ type RetryContext struct {
 
 	Delay func(ctx *RetryContext) time.Duration
 
+	// Backoff 为重试的退避策略，未指定 Delay 时使用，优先于 Interval。退避策略返回的等待
+	// 时间可以为0
+	Backoff Backoff
+
 	Interrupter chan struct{}
 
+	// Context 结束时重试被中断，与 Interrupter 的作用相同
+	Context context.Context
+
 	MaxRetry int
 
+	// MaxElapsedTime 为从第一次执行开始允许重试的最长时间，如果下一次重试的时间超过了此
+	// 限制，则不再重试。小于等于0时不限制
+	MaxElapsedTime time.Duration
+
 	Retrievable func(ctx *RetryContext) bool
 
 	Ignorable func(ctx *RetryContext) bool
 
+	// Breaker 为执行使用的熔断器，熔断器打开时直接失败，不再重试，也不计入重试次数
+	Breaker *CircuitBreaker
+
+	// OnAttempt 在每次执行失败后调用，可以用于记录每次执行的错误和下一次重试的等待时间
+	OnAttempt func(ctx *RetryContext, attempt Attempt)
+
 	Error error
 
+	// Errors 为每次执行失败的错误，重试最终失败时作为错误返回
+	Errors errors.Errors
+
 	Retry int
+
+	// LastDelay 为上一次重试的等待时间
+	LastDelay time.Duration
+
+	startTime time.Time
 }
 
 type Retry RetryContext
@@ -37,58 +63,140 @@ func MakeRetry(retry Retry) *RetryContext {
 	return c
 }
 
-func (c *RetryContext) do(retryTimer *timer.Timer) bool {
+// Attempt 为一次执行失败的信息
+type Attempt struct {
+	// Number 为执行的次数，从1开始
+	Number int
+	Err    error
+	// Retry 表示是否会进行下一次重试，Delay 为下一次重试的等待时间
+	Retry bool
+	Delay time.Duration
+}
+
+// next 判断执行失败后是否需要重试，如果需要重试，返回重试的等待时间
+func (c *RetryContext) next(err error, permanent bool) (bool, time.Duration) {
+	if permanent || err == InterruptedError {
+		return false, 0
+	}
 	maxRetry := c.MaxRetry
 	if maxRetry < 0 {
 		maxRetry = math.MaxInt
 	}
-	// do func
-	if err := c.Do(); err != nil {
-		// check error ignorable
-		if ignorable := c.Ignorable; ignorable != nil {
-			if ignorable(c) {
-				return false
-			}
-		}
-		c.Error = err
-		if err == InterruptedError {
-			return false
-		}
 
-		// check retrievable
-		if c.Retry >= maxRetry {
-			return false
-		}
-		if retrievable := c.Retrievable; retrievable != nil {
-			if !retrievable(c) {
-				return false
-			}
+	// check retrievable
+	if c.Retry >= maxRetry {
+		return false, 0
+	}
+	if retrievable := c.Retrievable; retrievable != nil {
+		if !retrievable(c) {
+			return false, 0
 		}
-		c.Retry++
+	}
+	c.Retry++
+
+	// get retry delay
+	var delay time.Duration
+	switch {
+	case c.Delay != nil:
+		delay = c.Delay(c)
+	case c.Backoff != nil:
+		delay = c.Backoff.Next(c.Retry, c.LastDelay)
+	default:
+		delay = c.Interval
+	}
+	if delay <= 0 && (c.Delay != nil || c.Backoff == nil) {
+		delay = time.Second
+	} else if delay < 0 {
+		delay = 0
+	}
+
+	// check max elapsed time
+	if c.MaxElapsedTime > 0 && time.Since(c.startTime)+delay > c.MaxElapsedTime {
+		c.Retry--
+		return false, 0
+	}
+	c.LastDelay = delay
+	return true, delay
+}
 
-		// get retry delay
-		delay := c.Interval
-		if c.Delay != nil {
-			delay = c.Delay(c)
+func (c *RetryContext) do(retryTimer *timer.Timer) bool {
+	attempt := c.Retry + 1
+	// check circuit breaker
+	var done func(err error)
+	if c.Breaker != nil {
+		var err error
+		if done, err = c.Breaker.Allow(); err != nil {
+			c.Error = err
+			c.Errors = append(c.Errors, err)
+			if onAttempt := c.OnAttempt; onAttempt != nil {
+				onAttempt(c, Attempt{Number: attempt, Err: err})
+			}
+			return false
 		}
-		if delay <= 0 {
-			delay = time.Second
+	}
+	// do func
+	err := c.Do()
+	if err != nil {
+		// check error ignorable，被忽略的错误对于熔断器同样视为成功
+		c.Error = err
+		if ignorable := c.Ignorable; ignorable != nil && ignorable(c) {
+			err = nil
 		}
+	}
+	if done != nil {
+		done(err)
+	}
+	if err == nil {
+		c.Error = nil
+		return false
+	}
+	// check error permanent
+	var permanentErr *PermanentError
+	permanent := errors.As(err, &permanentErr)
+	if permanent {
+		err = permanentErr.Err
+	}
+	c.Error = err
+	c.Errors = append(c.Errors, err)
 
+	retry, delay := c.next(err, permanent)
+	if onAttempt := c.OnAttempt; onAttempt != nil {
+		onAttempt(c, Attempt{Number: attempt, Err: err, Retry: retry, Delay: delay})
+	}
+	if retry {
 		retryTimer.After(delay)
-		return true
 	}
-	return false
+	return retry
 }
 
+// failed 返回重试最终失败的错误，如果重试被中断，返回 InterruptedError，否则返回每次执
+// 行失败的错误
+func (c *RetryContext) failed() error {
+	if c.Error == InterruptedError {
+		return InterruptedError
+	}
+	return c.Errors
+}
+
+// Todo 执行并在失败时重试，执行成功或错误被忽略时返回nil。重试被中断时返回
+// InterruptedError，否则返回 errors.Errors 类型的错误，其中按顺序记录了每次执行失败的
+// 错误(包括熔断器拒绝执行的错误)，可以使用 errors.Is 和 errors.As 匹配其中任意一个错误
 func (c *RetryContext) Todo() error {
 	retryTimer := timer.NewTimer(make(chan struct{}))
 	defer retryTimer.Stop()
+	c.startTime = time.Now()
+	c.Errors = nil
+	c.LastDelay = 0
+
+	var done <-chan struct{}
+	if c.Context != nil {
+		done = c.Context.Done()
+	}
 
 	if retry := c.do(retryTimer); c.Error == nil {
 		return nil
 	} else if !retry {
-		return c.Error
+		return c.failed()
 	}
 
 	for {
@@ -97,10 +205,12 @@ func (c *RetryContext) Todo() error {
 			if retry := c.do(retryTimer); c.Error == nil {
 				return nil
 			} else if !retry {
-				return c.Error
+				return c.failed()
 			}
 		case <-c.Interrupter:
 			return InterruptedError
+		case <-done:
+			return InterruptedError
 		}
 	}
 }
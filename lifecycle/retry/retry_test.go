@@ -0,0 +1,260 @@
+package retry
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"testing"
+	"time"
+)
+
+func TestBackoff(t *testing.T) {
+	exponential := ExponentialBackoff(time.Millisecond*100, 2, time.Second)
+	for retry, expect := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		if delay := exponential.Next(retry+1, 0); delay != expect*time.Millisecond {
+			t.Errorf("exponential backoff retry %d expect %s, got %s", retry+1, expect*time.Millisecond, delay)
+		}
+	}
+	if delay := exponential.Next(1000, 0); delay != time.Second {
+		t.Errorf("exponential backoff should not overflow, got %s", delay)
+	}
+
+	linear := LinearBackoff(time.Millisecond*100, time.Millisecond*50, time.Millisecond*200)
+	for retry, expect := range []time.Duration{100, 150, 200, 200} {
+		if delay := linear.Next(retry+1, 0); delay != expect*time.Millisecond {
+			t.Errorf("linear backoff retry %d expect %s, got %s", retry+1, expect*time.Millisecond, delay)
+		}
+	}
+
+	fullJitter := FullJitterBackoff(time.Millisecond*100, time.Second)
+	decorrelated := DecorrelatedJitterBackoff(time.Millisecond*100, time.Second)
+	var last time.Duration
+	for retry := 1; retry <= 100; retry++ {
+		if delay := fullJitter.Next(retry, 0); delay < 0 || delay > time.Second {
+			t.Fatalf("full jitter backoff out of range: %s", delay)
+		}
+		delay := decorrelated.Next(retry, last)
+		if delay < time.Millisecond*100 || delay > time.Second || (last > 0 && delay > last*3) {
+			t.Fatalf("decorrelated jitter backoff out of range: %s, last %s", delay, last)
+		}
+		last = delay
+	}
+}
+
+func TestRetryMaxElapsedTime(t *testing.T) {
+	failed := errors.New("failed")
+	var attempts int
+	begin := time.Now()
+	err := MakeRetry(Retry{
+		Do: func() error {
+			attempts++
+			return failed
+		},
+		Backoff:        ConstantBackoff(time.Millisecond * 20),
+		MaxRetry:       -1,
+		MaxElapsedTime: time.Millisecond * 110,
+	}).Todo()
+	// 调度延迟会影响执行的次数，只检查次数和时间的范围
+	if errs, is := err.(errors.Errors); !is || len(errs) != attempts || !errors.Is(err, failed) {
+		t.Errorf("expect errors of all attempts, got %v", err)
+	}
+	if attempts < 2 || attempts > 6 {
+		t.Errorf("expect 2 to 6 attempts, got %d", attempts)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Millisecond*(110+20) {
+		t.Errorf("retry should stop before max elapsed time, elapsed %s", elapsed)
+	}
+
+	attempts = 0
+	err = MakeRetry(Retry{
+		Do: func() error {
+			if attempts++; attempts < 3 {
+				return failed
+			}
+			return nil
+		},
+		Backoff:  ConstantBackoff(0),
+		MaxRetry: -1,
+	}).Todo()
+	if err != nil || attempts != 3 {
+		t.Errorf("expect success after 3 attempts, got %v after %d attempts", err, attempts)
+	}
+}
+
+func TestRetryAttempt(t *testing.T) {
+	failed := errors.New("failed")
+	denied := errors.New("denied")
+	var attempts []Attempt
+	err := MakeRetry(Retry{
+		Do: func() error {
+			if len(attempts) < 2 {
+				return failed
+			}
+			return Permanent(denied)
+		},
+		Backoff:  LinearBackoff(time.Millisecond, time.Millisecond, 0),
+		MaxRetry: 10,
+		OnAttempt: func(ctx *RetryContext, attempt Attempt) {
+			attempts = append(attempts, attempt)
+		},
+	}).Todo()
+	expect := []Attempt{
+		{Number: 1, Err: failed, Retry: true, Delay: time.Millisecond},
+		{Number: 2, Err: failed, Retry: true, Delay: time.Millisecond * 2},
+		{Number: 3, Err: denied},
+	}
+	if len(attempts) != len(expect) {
+		t.Fatalf("expect %d attempts, got %d", len(expect), len(attempts))
+	}
+	for i := range expect {
+		if attempts[i] != expect[i] {
+			t.Errorf("attempt %d expect %+v, got %+v", i+1, expect[i], attempts[i])
+		}
+	}
+	if !errors.Is(err, denied) || IsPermanent(err) {
+		t.Errorf("expect unwrapped permanent error in history, got %v", err)
+	}
+}
+
+func TestRetryIgnorable(t *testing.T) {
+	ignored := errors.New("ignored")
+	breaker := NewCircuitBreaker(Breaker{ConsecutiveFailures: 1})
+	ctx := MakeRetry(Retry{
+		Do:        func() error { return ignored },
+		Ignorable: func(ctx *RetryContext) bool { return ctx.Error == ignored },
+		Breaker:   breaker,
+	})
+	// 被忽略的错误不能使熔断器打开
+	for i := 0; i < 3; i++ {
+		if err := ctx.Todo(); err != nil {
+			t.Fatalf("ignored error should not fail, got %v", err)
+		}
+	}
+	if breaker.State() != BreakerClosed {
+		t.Errorf("ignored errors should not open breaker, got %s", breaker.State())
+	}
+
+	// 每次执行 Todo 时上一次重试的等待时间重新开始计算
+	var delays []time.Duration
+	ctx = MakeRetry(Retry{
+		Do: func() error { return ignored },
+		Backoff: BackoffFunc(func(retry int, last time.Duration) time.Duration {
+			delays = append(delays, last)
+			return time.Millisecond
+		}),
+		MaxRetry: 1,
+	})
+	ctx.Todo()
+	ctx.Retry = 0
+	ctx.Todo()
+	if len(delays) != 2 || delays[0] != 0 || delays[1] != 0 {
+		t.Errorf("last delay should be reset for each todo, got %v", delays)
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	failed := errors.New("failed")
+	var events []BreakerEvent
+	breaker := NewCircuitBreaker(Breaker{
+		Name:                "downstream",
+		ConsecutiveFailures: 3,
+		CoolDown:            time.Millisecond * 50,
+		HalfOpenRequests:    2,
+		OnStateChange:       func(event BreakerEvent) { events = append(events, event) },
+	})
+
+	var calls int
+	err := MakeRetry(Retry{
+		Do: func() error {
+			calls++
+			return failed
+		},
+		Backoff:  ConstantBackoff(0),
+		MaxRetry: 10,
+		Breaker:  breaker,
+	}).Todo()
+	var openErr *BreakerOpenError
+	if !errors.As(err, &openErr) || calls != 3 {
+		t.Fatalf("breaker should open after 3 failures and fail fast, got %v after %d calls", err, calls)
+	}
+	if breaker.State() != BreakerOpen {
+		t.Fatalf("expect breaker open, got %s", breaker.State())
+	}
+
+	time.Sleep(time.Millisecond * 60)
+	if breaker.State() != BreakerHalfOpen {
+		t.Fatalf("expect breaker half open after cool down, got %s", breaker.State())
+	}
+	done1, err1 := breaker.Allow()
+	done2, err2 := breaker.Allow()
+	if _, err3 := breaker.Allow(); err1 != nil || err2 != nil || err3 == nil {
+		t.Fatalf("half open breaker should allow exactly 2 trials, got %v, %v, %v", err1, err2, err3)
+	}
+	// 重复调用 done 只记录一次试探请求的结果
+	done1(nil)
+	done1(nil)
+	if breaker.State() != BreakerHalfOpen {
+		t.Fatalf("repeated done should count once, got %s", breaker.State())
+	}
+	done2(nil)
+	if breaker.State() != BreakerClosed {
+		t.Fatalf("expect breaker closed after trials passed, got %s", breaker.State())
+	}
+
+	expect := []BreakerState{BreakerOpen, BreakerHalfOpen, BreakerClosed}
+	if len(events) != len(expect) {
+		t.Fatalf("expect %d state events, got %d", len(expect), len(events))
+	}
+	for i, event := range events {
+		if event.To != expect[i] || event.Name != "downstream" {
+			t.Errorf("event %d expect to %s, got %+v", i, expect[i], event)
+		}
+	}
+	if events[0].Err != failed {
+		t.Errorf("open event should carry the failure, got %v", events[0].Err)
+	}
+
+	rate := NewCircuitBreaker(Breaker{FailureRate: 0.5, MinRequests: 4, Window: time.Second})
+	for i, err := range []error{nil, failed, nil, failed} {
+		rate.Do(func() error { return err })
+		if state := rate.State(); (i < 3 && state != BreakerClosed) || (i == 3 && state != BreakerOpen) {
+			t.Fatalf("after %d calls expect failure rate breaker %s", i+1, state)
+		}
+	}
+}
+
+func TestCircuitBreakerHalfOpenTrials(t *testing.T) {
+	failed := errors.New("failed")
+	breaker := NewCircuitBreaker(Breaker{ConsecutiveFailures: 1, CoolDown: time.Millisecond * 50})
+	breaker.Do(func() error { return failed })
+	time.Sleep(time.Millisecond * 60)
+
+	// 试探请求发生 panic 时计为失败，熔断器重新打开
+	func() {
+		defer func() {
+			if recover() == nil {
+				t.Error("panic should be propagated")
+			}
+		}()
+		breaker.Do(func() error { panic("boom") })
+	}()
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("panicked trial should reopen breaker, got %s", state)
+	}
+
+	// 调用者丢弃了 done，试探请求在冷却时间后超时
+	time.Sleep(time.Millisecond * 60)
+	if _, err := breaker.Allow(); err != nil {
+		t.Fatal(err)
+	}
+	var openErr *BreakerOpenError
+	if _, err := breaker.Allow(); !errors.As(err, &openErr) || openErr.RetryAfter <= 0 {
+		t.Fatalf("half open rejection should carry retry after, got %v", err)
+	}
+	time.Sleep(time.Millisecond * 60)
+	if state := breaker.State(); state != BreakerOpen {
+		t.Fatalf("timed out trial should reopen breaker, got %s", state)
+	}
+	time.Sleep(time.Millisecond * 60)
+	if err := breaker.Do(func() error { return nil }); err != nil || breaker.State() != BreakerClosed {
+		t.Fatalf("breaker should close after trial passed, got %v, %s", err, breaker.State())
+	}
+}
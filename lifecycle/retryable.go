@@ -1,6 +1,7 @@
 package lifecycle
 
 import (
+	"gitee.com/sy_183/common/lifecycle/retry"
 	"gitee.com/sy_183/common/timer"
 	"sync/atomic"
 	"time"
@@ -16,6 +17,12 @@ type Retryable[LIFECYCLE Lifecycle] struct {
 
 	lazyStart     atomic.Bool
 	retryInterval atomic.Int64
+	backoff       atomic.Value
+	restarts      atomic.Int64
+}
+
+type retryBackoff struct {
+	retry.Backoff
 }
 
 func NewRetryable[LIFECYCLE Lifecycle](lifecycle LIFECYCLE) *Retryable[LIFECYCLE] {
@@ -27,6 +34,10 @@ func NewRetryable[LIFECYCLE Lifecycle](lifecycle LIFECYCLE) *Retryable[LIFECYCLE
 	return r
 }
 
+func (r *Retryable[LIFECYCLE]) delegate() Lifecycle {
+	return r.Lifecycle
+}
+
 func (r *Retryable[LIFECYCLE]) Get() LIFECYCLE {
 	return r.lifecycle
 }
@@ -41,6 +52,21 @@ func (r *Retryable[LIFECYCLE]) SetRetryInterval(interval time.Duration) *Retryab
 	return r
 }
 
+// SetBackoff 设置重启的退避策略，设置后重启的等待时间由退避策略根据连续重启的次数计算，
+// 不再使用 SetRetryInterval 设置的时间。组件启动成功后连续重启的次数被重置
+func (r *Retryable[LIFECYCLE]) SetBackoff(backoff retry.Backoff) *Retryable[LIFECYCLE] {
+	r.backoff.Store(retryBackoff{Backoff: backoff})
+	return r
+}
+
+// retryDelay 返回第 retry 次连续重启的等待时间
+func (r *Retryable[LIFECYCLE]) retryDelay(retry int, last time.Duration) time.Duration {
+	if backoff, _ := r.backoff.Load().(retryBackoff); backoff.Backoff != nil {
+		return backoff.Next(retry, last)
+	}
+	return time.Duration(r.retryInterval.Load())
+}
+
 func (r *Retryable[LIFECYCLE]) start(_ Lifecycle, interrupter chan struct{}) (InterruptedRunFunc, error) {
 	var lazyStart bool
 
@@ -54,6 +80,16 @@ func (r *Retryable[LIFECYCLE]) start(_ Lifecycle, interrupter chan struct{}) (In
 			startRetryTimer.Stop()
 		}()
 
+		// 延迟启动时定时器第一次触发为组件的首次启动，不计入重启次数
+		started := !lazyStart
+		// 连续重启的次数和上一次重启的等待时间，用于计算退避时间
+		var retries int
+		var lastDelay time.Duration
+		retryAfter := func() {
+			retries++
+			lastDelay = r.retryDelay(retries, lastDelay)
+			startRetryTimer.After(lastDelay)
+		}
 		if lazyStart {
 			state = StateClosed
 			startRetryTimer.Trigger()
@@ -69,6 +105,10 @@ func (r *Retryable[LIFECYCLE]) start(_ Lifecycle, interrupter chan struct{}) (In
 				// 此时需要启动组件并添加启动完成的追踪器到组件
 				r.lifecycle.AddStartedFuture(runningFuture)
 				state = StateStarting
+				if started {
+					r.restarts.Add(1)
+				}
+				started = true
 				r.lifecycle.Background()
 			case err := <-runningFuture:
 				// 生命周期组件启动完成，如果启动错误，在这种情况下如果标记了中断，则直接退出，否则启动定时器，定时器
@@ -78,10 +118,11 @@ func (r *Retryable[LIFECYCLE]) start(_ Lifecycle, interrupter chan struct{}) (In
 					if r.interrupted {
 						return nil
 					}
-					startRetryTimer.After(time.Duration(r.retryInterval.Load()))
+					retryAfter()
 					continue
 				}
 				state.ToRunning()
+				retries, lastDelay = 0, 0
 				r.lifecycle.AddClosedFuture(closedFuture)
 			case <-closedFuture:
 				// 生命周期组件退出，如果标记了中断，则直接退出，否则启动定时器，定时器触发后执行重启
@@ -89,7 +130,7 @@ func (r *Retryable[LIFECYCLE]) start(_ Lifecycle, interrupter chan struct{}) (In
 				if r.interrupted {
 					return nil
 				}
-				startRetryTimer.After(time.Duration(r.retryInterval.Load()))
+				retryAfter()
 			case <-interrupter:
 				// 中断信号只会出现一次，如果此时生命周期组件为关闭状态，则直接退出，否则对组件执行关闭操作
 				r.interrupted = true
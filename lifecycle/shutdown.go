@@ -0,0 +1,191 @@
+package lifecycle
+
+import (
+	"context"
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/lock"
+	"time"
+)
+
+// ContextShutdowner 由可以在指定的截止时间内关闭的生命周期组件实现
+type ContextShutdowner interface {
+	ShutdownContext(ctx context.Context) error
+
+	CloseWithTimeout(timeout time.Duration) error
+}
+
+// closeContext 返回组件关闭时使用的上下文，通过 ShutdownContext 关闭组件时为调用者
+// 传入的上下文，否则为 context.Background()。组件在关闭子组件时使用此上下文限制等待
+// 子组件关闭的时间，从而使整个关闭过程不超过同一个截止时间
+func (l *DefaultLifecycle) closeContext() context.Context {
+	if ctx := l.closeCtx.Load(); ctx != nil {
+		return *ctx
+	}
+	return context.Background()
+}
+
+// Interruptible 由可以在关闭超时后被强制中断的生命周期组件实现
+type Interruptible interface {
+	Interrupt()
+}
+
+// Interrupt 强制中断正在关闭的组件，组件没有实现 Interruptible 时不做任何处理
+func Interrupt(lifecycle Lifecycle) {
+	if interruptible, ok := lookup[Interruptible](lifecycle); ok {
+		interruptible.Interrupt()
+	}
+}
+
+// Interrupt 强制中断正在关闭的组件。对于使用中断信号运行的组件，再次向运行函数发送中断
+// 信号，运行函数在处理关闭的过程中可以再次等待中断信号，收到后放弃剩余的关闭流程。组件
+// 没有在关闭中时不做任何处理
+func (l *DefaultLifecycle) Interrupt() {
+	if l.escalator == nil || !lock.RLockGet(l, func() bool { return l.Closing() }) {
+		return
+	}
+	l.escalator.escalate()
+}
+
+// DefaultShutdownGracePeriod 为默认的中断宽限时间
+const DefaultShutdownGracePeriod = time.Millisecond * 100
+
+type shutdownGracePeriodKey struct{}
+
+// WithShutdownGracePeriod 返回指定了中断宽限时间的关闭上下文。关闭上下文结束后仍未关闭
+// 的组件会被强制中断，并在宽限时间内继续等待组件关闭，超过宽限时间仍未关闭的组件才被认
+// 为是未关闭的。没有指定时使用 DefaultShutdownGracePeriod
+func WithShutdownGracePeriod(ctx context.Context, gracePeriod time.Duration) context.Context {
+	return context.WithValue(ctx, shutdownGracePeriodKey{}, gracePeriod)
+}
+
+func shutdownGracePeriod(ctx context.Context) time.Duration {
+	if gracePeriod, is := ctx.Value(shutdownGracePeriodKey{}).(time.Duration); is {
+		return gracePeriod
+	}
+	return DefaultShutdownGracePeriod
+}
+
+// ShutdownContext 关闭组件并等待组件退出，返回组件退出的错误。如果组件在上下文结束前仍
+// 未退出，则强制中断组件并在宽限时间内继续等待，仍未退出时放弃等待并返回
+// ShutdownTimeoutError。对于生命周期组、列表和图，上下文会传递给子组件，当上下文结束时，
+// 所有仍未关闭的子组件会被同时中断，然后返回记录了未关闭子组件名称的错误
+func (l *DefaultLifecycle) ShutdownContext(ctx context.Context) error {
+	lock.LockDo(l, func() {
+		if !l.Closed() {
+			l.closeCtx.Store(&ctx)
+		}
+	})
+	future := make(ChanFuture[error], 1)
+	if err := l.self().Close(future); err != nil {
+		return err
+	}
+	var done <-chan struct{}
+	if !l.boundedClose {
+		done = ctx.Done()
+	}
+	return awaitShutdown(l.self(), ctx, done, future)
+}
+
+func (l *DefaultLifecycle) CloseWithTimeout(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return l.ShutdownContext(ctx)
+}
+
+// awaitShutdown 等待组件关闭，done 结束后强制中断组件并在宽限时间内继续等待
+func awaitShutdown(lifecycle Lifecycle, ctx context.Context, done <-chan struct{}, future ChanFuture[error]) error {
+	select {
+	case err := <-future:
+		return err
+	case <-done:
+	}
+	Interrupt(lifecycle)
+	timer := time.NewTimer(shutdownGracePeriod(ctx))
+	defer timer.Stop()
+	select {
+	case err := <-future:
+		return err
+	case <-timer.C:
+		return NewShutdownTimeoutError("", nil)
+	}
+}
+
+// ShutdownContext 关闭组件并等待组件退出，如果组件没有实现 ContextShutdowner，则在上下
+// 文结束后强制中断组件，超过宽限时间仍未退出时放弃等待并返回 ShutdownTimeoutError，组件
+// 的子组件不受上下文的限制
+func ShutdownContext(lifecycle Lifecycle, ctx context.Context) error {
+	if shutdowner, ok := lookup[ContextShutdowner](lifecycle); ok {
+		return shutdowner.ShutdownContext(ctx)
+	}
+	future := make(ChanFuture[error], 1)
+	if err := lifecycle.Close(future); err != nil {
+		return err
+	}
+	return awaitShutdown(lifecycle, ctx, ctx.Done(), future)
+}
+
+// CloseWithTimeout 关闭组件并最多等待 timeout 的时间，参考 ShutdownContext
+func CloseWithTimeout(lifecycle Lifecycle, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return ShutdownContext(lifecycle, ctx)
+}
+
+// interruptChildren 关闭并强制中断所有剩余的子组件，并在宽限时间内等待子组件关闭，返回
+// 仍未关闭的子组件名称，names 与 children 一一对应
+func interruptChildren(ctx context.Context, names []string, children []Lifecycle) (stuck []string) {
+	if len(children) == 0 {
+		return nil
+	}
+	futures := make([]ChanFuture[error], len(children))
+	for i, child := range children {
+		futures[i] = make(ChanFuture[error], 1)
+		if err := child.Close(futures[i]); err != nil {
+			// 子组件拒绝关闭，不会完成关闭的 future
+			futures[i] = nil
+			continue
+		}
+		Interrupt(child)
+	}
+	timer := time.NewTimer(shutdownGracePeriod(ctx))
+	defer timer.Stop()
+	expired := false
+	for i, future := range futures {
+		if future == nil {
+			stuck = append(stuck, names[i])
+			continue
+		}
+		if !expired {
+			select {
+			case <-future:
+				continue
+			case <-timer.C:
+				expired = true
+			}
+		}
+		select {
+		case <-future:
+		default:
+			stuck = append(stuck, names[i])
+		}
+	}
+	return
+}
+
+// stuckChildren 根据子组件关闭返回的错误获取未关闭的子组件名称，如果子组件本身包含未
+// 关闭的子组件，则使用'/'连接名称。子组件关闭超时以外的错误为子组件退出的错误，不影响
+// 子组件是否关闭
+func stuckChildren(name string, err error) []string {
+	var timeoutErr *ShutdownTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		return nil
+	}
+	if len(timeoutErr.Stuck) > 0 {
+		stuck := make([]string, len(timeoutErr.Stuck))
+		for i, s := range timeoutErr.Stuck {
+			stuck[i] = name + "/" + s
+		}
+		return stuck
+	}
+	return []string{name}
+}
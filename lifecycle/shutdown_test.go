@@ -0,0 +1,143 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestShutdownContext(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	stuck := NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+		<-interrupter
+		<-release
+		return nil
+	})
+	inner := NewGroup()
+	inner.MustAdd("stuck", stuck)
+	inner.MustAdd("normal", NewWithInterruptedRun(nil, InterrupterHoldRun))
+	g := NewGroup()
+	g.MustAdd("inner", inner)
+	g.MustAdd("normal", NewWithInterruptedRun(nil, InterrupterHoldRun))
+	if err := g.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Now()
+	err := CloseWithTimeout(g, time.Millisecond*100)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("shutdown takes too long(%s)", elapsed)
+	}
+	var timeoutErr *ShutdownTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expect shutdown timeout error, got %v", err)
+	}
+	if len(timeoutErr.Stuck) != 1 || timeoutErr.Stuck[0] != "inner/stuck" {
+		t.Errorf("unexpected stuck children %v", timeoutErr.Stuck)
+	}
+}
+
+func TestShutdownContextSlowChild(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	newStuck := func() Lifecycle {
+		return NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+			<-interrupter
+			<-release
+			return nil
+		})
+	}
+	// 关闭上下文结束后被中断的子组件需要一小段时间完成关闭，不应该被认为是未关闭的
+	newSlow := func() Lifecycle {
+		return NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+			<-interrupter
+			time.Sleep(time.Millisecond * 20)
+			return nil
+		})
+	}
+
+	list := NewList()
+	list.MustAppend(newSlow())
+	list.MustAppend(newStuck())
+	graph := NewGraph()
+	graph.MustAdd("slow", newSlow())
+	graph.MustAdd("stuck", newStuck(), "slow")
+	supervisor := NewSupervisor(OneForOne)
+	supervisor.MustAdd("slow", newSlow())
+	supervisor.MustAdd("stuck", newStuck())
+
+	for _, c := range []struct {
+		lifecycle Lifecycle
+		stuck     string
+	}{{list, "1"}, {graph, "stuck"}, {supervisor, "stuck"}} {
+		if err := c.lifecycle.Start(); err != nil {
+			t.Fatal(err)
+		}
+		err := CloseWithTimeout(c.lifecycle, time.Millisecond*50)
+		var timeoutErr *ShutdownTimeoutError
+		if !errors.As(err, &timeoutErr) {
+			t.Fatalf("expect shutdown timeout error, got %v", err)
+		}
+		if len(timeoutErr.Stuck) != 1 || timeoutErr.Stuck[0] != c.stuck {
+			t.Errorf("%T: unexpected stuck children %v", c.lifecycle, timeoutErr.Stuck)
+		}
+	}
+}
+
+func TestShutdownContextInterrupt(t *testing.T) {
+	// 关闭超时后组件再次收到中断信号，放弃剩余的关闭流程
+	release := make(chan struct{})
+	defer close(release)
+	l := NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+		<-interrupter
+		select {
+		case <-interrupter:
+		case <-release:
+		}
+		return nil
+	})
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CloseWithTimeout(l, time.Millisecond*20); err != nil {
+		t.Errorf("interrupted lifecycle should be closed, got %v", err)
+	}
+
+	exitErr := errors.New("exit error")
+	l = NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+		<-interrupter
+		return exitErr
+	})
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	if err := CloseWithTimeout(l, time.Second); err != exitErr {
+		t.Errorf("expect exit error, got %v", err)
+	}
+}
+
+func TestShutdownGracePeriod(t *testing.T) {
+	slow := NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+		<-interrupter
+		time.Sleep(time.Millisecond * 50)
+		return nil
+	}, WithName("slow"))
+	list := NewList()
+	list.MustAppend(slow)
+	if err := list.Start(); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*10)
+	defer cancel()
+	err := ShutdownContext(list, WithShutdownGracePeriod(ctx, time.Millisecond))
+	var timeoutErr *ShutdownTimeoutError
+	if !errors.As(err, &timeoutErr) {
+		t.Fatalf("expect shutdown timeout error, got %v", err)
+	}
+	if len(timeoutErr.Stuck) != 1 || timeoutErr.Stuck[0] != "slow" {
+		t.Errorf("unexpected stuck children %v", timeoutErr.Stuck)
+	}
+	<-list.ClosedWaiter()
+}
@@ -9,6 +9,9 @@ const (
 	StateStarting
 	StateRunning
 	StateClosing
+	StatePausing
+	StatePaused
+	StateResuming
 )
 
 type StateInfo struct {
@@ -22,6 +25,9 @@ var StateInfos = []StateInfo{
 	{State: StateStarting, UpperName: "STARTING"},
 	{State: StateRunning, UpperName: "RUNNING"},
 	{State: StateClosing, UpperName: "CLOSING"},
+	{State: StatePausing, UpperName: "PAUSING"},
+	{State: StatePaused, UpperName: "PAUSED"},
+	{State: StateResuming, UpperName: "RESUMING"},
 }
 
 func init() {
@@ -48,6 +54,28 @@ func (s State) Closing() bool {
 	return s == StateClosing
 }
 
+func (s State) Pausing() bool {
+	return s == StatePausing
+}
+
+func (s State) Paused() bool {
+	return s == StatePaused
+}
+
+func (s State) Resuming() bool {
+	return s == StateResuming
+}
+
+// Started 判断组件是否已经启动完成并且没有开始关闭，暂停中、已暂停和恢复中的组件同样被认
+// 为是已经启动完成的
+func (s State) Started() bool {
+	switch s {
+	case StateRunning, StatePausing, StatePaused, StateResuming:
+		return true
+	}
+	return false
+}
+
 func (s State) String() string {
 	for _, info := range StateInfos {
 		if s == info.State {
@@ -57,8 +85,12 @@ func (s State) String() string {
 	return "UNKNOWN"
 }
 
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
 func (s State) check() {
-	if s > StateClosing {
+	if s > StateResuming {
 		panic(NewUnknownStateError("", s))
 	}
 }
@@ -88,3 +120,27 @@ func (s *State) ToClosing() {
 	}
 	*s = StateClosing
 }
+
+func (s *State) ToPausing() {
+	s.check()
+	if !s.Running() {
+		panic(NewStateNotAllowSwitchError("", s.String(), StatePausing.String()))
+	}
+	*s = StatePausing
+}
+
+func (s *State) ToPaused() {
+	s.check()
+	if !s.Pausing() {
+		panic(NewStateNotAllowSwitchError("", s.String(), StatePaused.String()))
+	}
+	*s = StatePaused
+}
+
+func (s *State) ToResuming() {
+	s.check()
+	if !s.Paused() {
+		panic(NewStateNotAllowSwitchError("", s.String(), StateResuming.String()))
+	}
+	*s = StateResuming
+}
@@ -0,0 +1,594 @@
+package lifecycle
+
+import (
+	"gitee.com/sy_183/common/assert"
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/lifecycle/retry"
+	"gitee.com/sy_183/common/lock"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const SupervisorFieldName = "$supervisor"
+
+const (
+	DefaultSupervisorInitialBackoff = time.Millisecond * 100
+	DefaultSupervisorMaxBackoff     = time.Second * 30
+	DefaultSupervisorBackoffJitter  = 0.2
+	DefaultSupervisorMaxRestarts    = 10
+	DefaultSupervisorRestartWindow  = time.Minute
+)
+
+// RestartStrategy 为监督者在子组件退出后重启子组件的策略
+type RestartStrategy int
+
+const (
+	// OneForOne 只重启退出的子组件
+	OneForOne = RestartStrategy(iota)
+	// OneForAll 关闭并重启所有子组件
+	OneForAll
+	// RestForOne 关闭并重启退出的子组件以及在其之后添加的子组件
+	RestForOne
+)
+
+func (s RestartStrategy) String() string {
+	switch s {
+	case OneForOne:
+		return "one-for-one"
+	case OneForAll:
+		return "one-for-all"
+	case RestForOne:
+		return "rest-for-one"
+	default:
+		return "unknown"
+	}
+}
+
+// RestartPolicy 为子组件退出后是否需要重启的策略
+type RestartPolicy int
+
+const (
+	// RestartPermanent 子组件退出后总是重启
+	RestartPermanent = RestartPolicy(iota)
+	// RestartTransient 子组件启动失败或运行返回错误后重启，正常退出后不再重启
+	RestartTransient
+	// RestartTemporary 子组件退出后不再重启，也不会因为其他子组件退出而重启
+	RestartTemporary
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartPermanent:
+		return "permanent"
+	case RestartTransient:
+		return "transient"
+	case RestartTemporary:
+		return "temporary"
+	default:
+		return "unknown"
+	}
+}
+
+type OnChildRestartFunc = func(child *SupervisorLifecycleHolder, err error)
+
+type SupervisorLifecycleHolder struct {
+	Lifecycle
+	name       string
+	index      int
+	supervisor *Supervisor
+
+	policy atomic.Int64
+
+	restarts    atomic.Int64
+	lastErr     atomic.Pointer[error]
+	lastRestart atomic.Int64
+
+	// 以下字段只在监督者的运行协程中访问
+	generation int64
+	stopped    bool
+	pending    bool
+	restartAt  time.Time
+	startTime  time.Time
+	attempt    int
+	lastDelay  time.Duration
+	// starting 表示子组件被重启后还没有启动完成
+	starting bool
+}
+
+func (h *SupervisorLifecycleHolder) Name() string {
+	return h.name
+}
+
+func (h *SupervisorLifecycleHolder) Supervisor() *Supervisor {
+	return h.supervisor
+}
+
+func (h *SupervisorLifecycleHolder) SetRestartPolicy(policy RestartPolicy) *SupervisorLifecycleHolder {
+	h.policy.Store(int64(policy))
+	return h
+}
+
+func (h *SupervisorLifecycleHolder) RestartPolicy() RestartPolicy {
+	return RestartPolicy(h.policy.Load())
+}
+
+// Restarts 返回子组件被监督者重启的次数
+func (h *SupervisorLifecycleHolder) Restarts() int64 {
+	return h.restarts.Load()
+}
+
+// LastError 返回子组件最近一次启动失败或退出时返回的错误
+func (h *SupervisorLifecycleHolder) LastError() error {
+	if err := h.lastErr.Load(); err != nil {
+		return *err
+	}
+	return nil
+}
+
+// LastRestart 返回子组件最近一次被重启的时间，如果没有被重启过，返回零值
+func (h *SupervisorLifecycleHolder) LastRestart() time.Time {
+	if t := h.lastRestart.Load(); t != 0 {
+		return time.Unix(0, t)
+	}
+	return time.Time{}
+}
+
+func (h *SupervisorLifecycleHolder) shouldRestart(err error) bool {
+	switch h.RestartPolicy() {
+	case RestartPermanent:
+		return true
+	case RestartTransient:
+		return err != nil
+	default:
+		return false
+	}
+}
+
+// SupervisorChildStats 为监督者中子组件的运行统计信息
+type SupervisorChildStats struct {
+	Name        string        `json:"name"`
+	Policy      RestartPolicy `json:"policy"`
+	State       State         `json:"state"`
+	Restarts    int64         `json:"restarts"`
+	LastError   error         `json:"-"`
+	LastRestart time.Time     `json:"lastRestart"`
+}
+
+// supervisorChildRef 记录了子组件及其所属的运行代数，子组件被监督者主动关闭或重启后，
+// 旧的代数产生的启动和退出事件将被忽略
+type supervisorChildRef struct {
+	*SupervisorLifecycleHolder
+	generation int64
+}
+
+type (
+	supervisorLifecycleContext = childLifecycleContext[supervisorChildRef]
+	supervisorLifecycleChannel = childLifecycleChannel[supervisorChildRef]
+)
+
+// Supervisor 管理多个子组件，并在子组件退出后根据重启策略和子组件的重启方式重启子组件。
+// 重启的间隔时间由退避策略计算，默认按照指数退避并添加随机抖动。OneForAll 和 RestForOne
+// 策略下被重启的子组件按照添加的顺序依次启动。如果在指定的时间窗口内重启的次数超过限制，
+// 则监督者关闭所有子组件并以 RestartIntensityError 退出
+type Supervisor struct {
+	Lifecycle
+	lifecycle *DefaultLifecycle
+
+	children     []*SupervisorLifecycleHolder
+	childrenLock sync.Mutex
+	names        map[string]*SupervisorLifecycleHolder
+
+	strategy      atomic.Int64
+	backoff       atomic.Value
+	maxRestarts   atomic.Int64
+	restartWindow atomic.Int64
+
+	onChildRestart     []OnChildRestartFunc
+	onChildRestartLock sync.Mutex
+
+	restartTimes   []time.Time
+	restartTimer   *time.Timer
+	runningChannel *supervisorLifecycleChannel
+	closedChannel  *supervisorLifecycleChannel
+}
+
+func NewSupervisor(strategy RestartStrategy) *Supervisor {
+	s := &Supervisor{
+		names:          make(map[string]*SupervisorLifecycleHolder),
+		runningChannel: newChildLifecycleChannel[supervisorChildRef](),
+		closedChannel:  newChildLifecycleChannel[supervisorChildRef](),
+	}
+	s.strategy.Store(int64(strategy))
+	s.SetBackoff(retry.JitterBackoff(retry.ExponentialBackoff(DefaultSupervisorInitialBackoff, 2, DefaultSupervisorMaxBackoff), DefaultSupervisorBackoffJitter))
+	s.maxRestarts.Store(DefaultSupervisorMaxRestarts)
+	s.restartWindow.Store(int64(DefaultSupervisorRestartWindow))
+	s.lifecycle = NewWithInterruptedStart(s.start)
+	s.lifecycle.boundedClose = true
+	s.lifecycle.pauser = FuncPauser(s.pause, s.resume)
+	s.Lifecycle = s.lifecycle
+	return s
+}
+
+func (s *Supervisor) delegate() Lifecycle {
+	return s.lifecycle
+}
+
+func (s *Supervisor) SetStrategy(strategy RestartStrategy) *Supervisor {
+	s.strategy.Store(int64(strategy))
+	return s
+}
+
+func (s *Supervisor) Strategy() RestartStrategy {
+	return RestartStrategy(s.strategy.Load())
+}
+
+// SetBackoff 设置重启的退避策略，重启的等待时间由退避策略根据子组件连续重启的次数计算。
+// 默认为初始等待时间 DefaultSupervisorInitialBackoff、最大等待时间
+// DefaultSupervisorMaxBackoff 的指数退避，并添加 DefaultSupervisorBackoffJitter 比例的随机
+// 抖动。backoff 为 nil 时不等待
+func (s *Supervisor) SetBackoff(backoff retry.Backoff) *Supervisor {
+	if backoff == nil {
+		backoff = retry.ConstantBackoff(0)
+	}
+	s.backoff.Store(retryBackoff{Backoff: backoff})
+	return s
+}
+
+// SetIntensity 设置重启强度限制，在 window 时间内重启的次数超过 maxRestarts 后监督者退出
+func (s *Supervisor) SetIntensity(maxRestarts int, window time.Duration) *Supervisor {
+	s.maxRestarts.Store(int64(maxRestarts))
+	s.restartWindow.Store(int64(window))
+	return s
+}
+
+func (s *Supervisor) OnChildRestart(onChildRestart OnChildRestartFunc) *Supervisor {
+	if onChildRestart != nil {
+		lock.LockDo(&s.onChildRestartLock, func() { s.onChildRestart = append(s.onChildRestart, onChildRestart) })
+	}
+	return s
+}
+
+func (s *Supervisor) doOnChildRestart(child *SupervisorLifecycleHolder, err error) {
+	lock.LockDo(&s.onChildRestartLock, func() {
+		for _, callback := range s.onChildRestart {
+			callback(child, err)
+		}
+	})
+}
+
+// Add 添加子组件，子组件默认的重启方式为 RestartPermanent，只有在监督者关闭时才可以添加
+func (s *Supervisor) Add(name string, lifecycle Lifecycle) (*SupervisorLifecycleHolder, error) {
+	return lock.RLockGetDouble(s.lifecycle, func() (*SupervisorLifecycleHolder, error) {
+		if !s.lifecycle.Closed() {
+			return nil, NewStateNotClosedError("")
+		}
+		return lock.LockGetDouble(&s.childrenLock, func() (*SupervisorLifecycleHolder, error) {
+			if _, has := s.names[name]; has {
+				return nil, errors.New("生命周期组件已经存在")
+			}
+			child := &SupervisorLifecycleHolder{
+				Lifecycle:  lifecycle,
+				name:       name,
+				index:      len(s.children),
+				supervisor: s,
+			}
+			child.SetRestartPolicy(RestartPermanent)
+			child.SetField(SupervisorFieldName, s)
+			child.SetDefaultField(NameFieldName, name)
+			s.children = append(s.children, child)
+			s.names[name] = child
+			return child, nil
+		})
+	})
+}
+
+func (s *Supervisor) MustAdd(name string, lifecycle Lifecycle) *SupervisorLifecycleHolder {
+	return assert.Must(s.Add(name, lifecycle))
+}
+
+func (s *Supervisor) Get(name string) *SupervisorLifecycleHolder {
+	return lock.LockGet(&s.childrenLock, func() *SupervisorLifecycleHolder { return s.names[name] })
+}
+
+func (s *Supervisor) getChildren() []*SupervisorLifecycleHolder {
+	return lock.LockGet(&s.childrenLock, func() []*SupervisorLifecycleHolder {
+		return append([]*SupervisorLifecycleHolder(nil), s.children...)
+	})
+}
+
+// Stats 返回所有子组件的重启次数、最近一次的错误等统计信息
+func (s *Supervisor) Stats() []SupervisorChildStats {
+	children := s.getChildren()
+	stats := make([]SupervisorChildStats, len(children))
+	for i, child := range children {
+		stats[i] = SupervisorChildStats{
+			Name:        child.name,
+			Policy:      child.RestartPolicy(),
+			State:       GetState(child),
+			Restarts:    child.Restarts(),
+			LastError:   child.LastError(),
+			LastRestart: child.LastRestart(),
+		}
+	}
+	return stats
+}
+
+// restartDelay 计算子组件下一次重启的等待时间
+func (s *Supervisor) restartDelay(child *SupervisorLifecycleHolder) time.Duration {
+	backoff, _ := s.backoff.Load().(retryBackoff)
+	delay := backoff.Next(child.attempt+1, child.lastDelay)
+	if delay < 0 {
+		delay = 0
+	}
+	child.lastDelay = delay
+	return delay
+}
+
+// exceedIntensity 记录一次重启，并检查在时间窗口内重启的次数是否超过限制
+func (s *Supervisor) exceedIntensity(now time.Time) bool {
+	window := time.Duration(s.restartWindow.Load())
+	i := 0
+	for ; i < len(s.restartTimes); i++ {
+		if now.Sub(s.restartTimes[i]) < window {
+			break
+		}
+	}
+	s.restartTimes = append(s.restartTimes[i:], now)
+	return int64(len(s.restartTimes)) > s.maxRestarts.Load()
+}
+
+func (s *Supervisor) launch(child *SupervisorLifecycleHolder) {
+	child.pending, child.starting = false, true
+	child.generation++
+	child.AddStartedFuture(supervisorLifecycleContext{
+		Lifecycle: supervisorChildRef{SupervisorLifecycleHolder: child, generation: child.generation},
+		channel:   s.runningChannel,
+	})
+	child.Background()
+}
+
+func (s *Supervisor) shutdownChildren(children []*SupervisorLifecycleHolder) (stuck []string) {
+	ctx := s.lifecycle.closeContext()
+	var names []string
+	var interrupted []Lifecycle
+	for i := len(children) - 1; i >= 0; i-- {
+		child := children[i]
+		// 增加代数，忽略子组件关闭后产生的事件
+		child.generation++
+		child.pending, child.starting = false, false
+		if ctx.Err() != nil {
+			// 关闭超时，不再按照顺序等待子组件关闭，直接中断剩余的所有子组件
+			names = append(names, child.name)
+			interrupted = append(interrupted, child)
+			continue
+		}
+		stuck = append(stuck, stuckChildren(child.name, ShutdownContext(child, ctx))...)
+	}
+	return append(stuck, interruptChildren(ctx, names, interrupted)...)
+}
+
+func (s *Supervisor) resetRestartTimer() {
+	if !s.restartTimer.Stop() {
+		select {
+		case <-s.restartTimer.C:
+		default:
+		}
+	}
+	if s.ordered() && s.restarting() {
+		// 等待正在重启的子组件启动完成后再重启下一个子组件
+		return
+	}
+	var earliest time.Time
+	for _, child := range s.children {
+		if child.pending && (earliest.IsZero() || child.restartAt.Before(earliest)) {
+			earliest = child.restartAt
+		}
+	}
+	if !earliest.IsZero() {
+		s.restartTimer.Reset(time.Until(earliest))
+	}
+}
+
+// handleExit 处理子组件启动失败或退出，根据重启策略关闭受影响的子组件并安排重启。如果
+// 重启的次数超过限制，则关闭所有子组件并返回错误
+func (s *Supervisor) handleExit(child *SupervisorLifecycleHolder, err error) error {
+	now := time.Now()
+	child.generation++
+	child.starting = false
+	if err != nil {
+		child.lastErr.Store(&err)
+	}
+	if !child.shouldRestart(err) {
+		child.stopped = true
+		return nil
+	}
+	if s.exceedIntensity(now) {
+		s.shutdownChildren(s.children)
+		return NewRestartIntensityError("监督者", int(s.maxRestarts.Load()), time.Duration(s.restartWindow.Load()), child.name, err)
+	}
+	if !child.startTime.IsZero() && now.Sub(child.startTime) >= time.Duration(s.restartWindow.Load()) {
+		// 子组件已经稳定运行了一个时间窗口，重新计算退避时间
+		child.attempt, child.lastDelay = 0, 0
+	}
+	restartAt := now.Add(s.restartDelay(child))
+	child.attempt++
+
+	var affected []*SupervisorLifecycleHolder
+	switch s.Strategy() {
+	case OneForAll:
+		affected = s.children
+	case RestForOne:
+		affected = s.children[child.index:]
+	default:
+		affected = []*SupervisorLifecycleHolder{child}
+	}
+	var siblings []*SupervisorLifecycleHolder
+	for _, sibling := range affected {
+		if sibling != child && !sibling.stopped && !sibling.pending {
+			siblings = append(siblings, sibling)
+		}
+	}
+	s.shutdownChildren(siblings)
+	for _, c := range affected {
+		if c.stopped {
+			continue
+		}
+		if c != child && c.RestartPolicy() == RestartTemporary {
+			c.stopped = true
+			continue
+		}
+		c.pending, c.restartAt = true, restartAt
+	}
+	s.resetRestartTimer()
+	return nil
+}
+
+// ordered 判断是否需要按照添加的顺序依次重启子组件，OneForAll 和 RestForOne 策略下，
+// 后面的子组件可能依赖前面的子组件，只有前一个子组件启动完成后才重启下一个子组件
+func (s *Supervisor) ordered() bool {
+	return s.Strategy() != OneForOne
+}
+
+// restarting 判断是否有子组件被重启后还没有启动完成
+func (s *Supervisor) restarting() bool {
+	for _, child := range s.children {
+		if child.starting {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Supervisor) restartPending() {
+	now := time.Now()
+	ordered := s.ordered()
+	if !ordered || !s.restarting() {
+		for _, child := range s.children {
+			if !child.pending {
+				continue
+			}
+			if child.restartAt.After(now) {
+				if ordered {
+					break
+				}
+				continue
+			}
+			child.restarts.Add(1)
+			child.lastRestart.Store(now.UnixNano())
+			s.doOnChildRestart(child, child.LastError())
+			s.launch(child)
+			if ordered {
+				break
+			}
+		}
+	}
+	s.resetRestartTimer()
+}
+
+func (s *Supervisor) handleRunningSignal() error {
+	for _, ctx := range s.runningChannel.Pop() {
+		child := ctx.Lifecycle
+		if child.generation != child.SupervisorLifecycleHolder.generation {
+			continue
+		}
+		if ctx.err != nil {
+			if err := s.handleExit(child.SupervisorLifecycleHolder, ctx.err); err != nil {
+				return err
+			}
+			continue
+		}
+		child.starting = false
+		child.startTime = time.Now()
+		child.AddClosedFuture(supervisorLifecycleContext{
+			Lifecycle: child,
+			channel:   s.closedChannel,
+		})
+	}
+	if s.ordered() {
+		// 继续重启下一个等待重启的子组件
+		s.restartPending()
+	}
+	return nil
+}
+
+func (s *Supervisor) handleClosedSignal() error {
+	for _, ctx := range s.closedChannel.Pop() {
+		child := ctx.Lifecycle
+		if child.generation != child.SupervisorLifecycleHolder.generation {
+			continue
+		}
+		if err := s.handleExit(child.SupervisorLifecycleHolder, ctx.err); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *Supervisor) start(_ Lifecycle, interrupter chan struct{}) (runFn InterruptedRunFunc, err error) {
+	defer func() {
+		if err != nil {
+			s.reset()
+		}
+	}()
+	s.restartTimer = time.NewTimer(0)
+	<-s.restartTimer.C
+	for i, child := range s.children {
+		child.stopped, child.pending, child.attempt = false, false, 0
+		future := make(ChanFuture[error], 1)
+		child.generation++
+		child.AddStartedFuture(future)
+		child.Background()
+		select {
+		case err := <-future:
+			if err != nil {
+				child.lastErr.Store(&err)
+				s.shutdownChildren(s.children[:i])
+				return nil, err
+			}
+			child.startTime = time.Now()
+			child.AddClosedFuture(supervisorLifecycleContext{
+				Lifecycle: supervisorChildRef{SupervisorLifecycleHolder: child, generation: child.generation},
+				channel:   s.closedChannel,
+			})
+		case <-interrupter:
+			if stuck := s.shutdownChildren(s.children[:i+1]); len(stuck) > 0 {
+				return nil, NewShutdownTimeoutError("监督者", stuck)
+			}
+			return nil, NewInterruptedError("监督者", "启动")
+		}
+	}
+	return s.run, nil
+}
+
+func (s *Supervisor) run(_ Lifecycle, interrupter chan struct{}) error {
+	defer s.reset()
+	for {
+		select {
+		case <-s.runningChannel.Signal():
+			if err := s.handleRunningSignal(); err != nil {
+				return err
+			}
+		case <-s.closedChannel.Signal():
+			if err := s.handleClosedSignal(); err != nil {
+				return err
+			}
+		case <-s.restartTimer.C:
+			s.restartPending()
+		case <-interrupter:
+			if stuck := s.shutdownChildren(s.children); len(stuck) > 0 {
+				return NewShutdownTimeoutError("监督者", stuck)
+			}
+			return nil
+		}
+	}
+}
+
+func (s *Supervisor) reset() {
+	if s.restartTimer != nil {
+		s.restartTimer.Stop()
+	}
+	s.restartTimes = nil
+	s.runningChannel = newChildLifecycleChannel[supervisorChildRef]()
+	s.closedChannel = newChildLifecycleChannel[supervisorChildRef]()
+}
@@ -0,0 +1,107 @@
+package lifecycle
+
+import (
+	"errors"
+	"gitee.com/sy_183/common/lifecycle/retry"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSupervisorOneForAll(t *testing.T) {
+	var crashes, siblingStarts atomic.Int64
+	s := NewSupervisor(OneForAll).SetBackoff(retry.ExponentialBackoff(time.Millisecond*10, 2, time.Millisecond*50))
+	s.MustAdd("sibling", NewWithInterruptedRun(func(Lifecycle, chan struct{}) error {
+		siblingStarts.Add(1)
+		return nil
+	}, InterrupterHoldRun))
+	crasher := s.MustAdd("crasher", NewWithInterruptedRun(nil, func(_ Lifecycle, interrupter chan struct{}) error {
+		if crashes.Add(1) <= 2 {
+			return errors.New("crash")
+		}
+		<-interrupter
+		return nil
+	}))
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond * 500)
+	if restarts := crasher.Restarts(); restarts != 2 {
+		t.Errorf("expect 2 restarts, got %d", restarts)
+	}
+	if starts := siblingStarts.Load(); starts != 3 {
+		t.Errorf("expect sibling started 3 times, got %d", starts)
+	}
+	if crasher.LastError() == nil {
+		t.Error("expect last error recorded")
+	}
+	if err := CloseWithTimeout(s, time.Second); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestSupervisorOrderedRestart(t *testing.T) {
+	for _, strategy := range []RestartStrategy{OneForAll, RestForOne} {
+		r := new(graphTestRecorder)
+		var crashes atomic.Int64
+		newChild := func(name string, crash bool) Lifecycle {
+			return NewWithInterruptedRun(func(Lifecycle, chan struct{}) error {
+				r.record("start " + name)
+				time.Sleep(time.Millisecond * 10)
+				r.record("started " + name)
+				return nil
+			}, func(_ Lifecycle, interrupter chan struct{}) error {
+				if crash && crashes.Add(1) == 1 {
+					return errors.New("crash")
+				}
+				<-interrupter
+				return nil
+			})
+		}
+		s := NewSupervisor(strategy).SetBackoff(nil)
+		s.MustAdd("a", newChild("a", true))
+		s.MustAdd("b", newChild("b", false))
+		s.MustAdd("c", newChild("c", false))
+		if err := s.Start(); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(time.Millisecond * 200)
+		if err := CloseWithTimeout(s, time.Second); err != nil {
+			t.Fatal(err)
+		}
+		// 重启时每个子组件在前一个子组件启动完成后才开始启动
+		r.mu.Lock()
+		events := append([]string(nil), r.events...)
+		r.mu.Unlock()
+		expect := []string{"a", "b", "c", "a", "b", "c"}
+		if len(events) != len(expect)*2 {
+			t.Fatalf("%s: unexpected events %v", strategy, events)
+		}
+		for i, name := range expect {
+			if events[i*2] != "start "+name || events[i*2+1] != "started "+name {
+				t.Fatalf("%s: children should restart in order, events %v", strategy, events)
+			}
+		}
+	}
+}
+
+func TestSupervisorIntensity(t *testing.T) {
+	s := NewSupervisor(OneForOne).SetBackoff(retry.ExponentialBackoff(time.Millisecond, 2, time.Millisecond*5)).SetIntensity(3, time.Second)
+	s.MustAdd("crasher", NewWithRun(nil, func(Lifecycle) error { return errors.New("crash") }, nil))
+	temporary := s.MustAdd("temporary", NewWithRun(nil, nil, nil)).SetRestartPolicy(RestartTemporary)
+	if err := s.Start(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case err := <-s.ClosedWaiter():
+		var intensityErr *RestartIntensityError
+		if !errors.As(err, &intensityErr) || intensityErr.Child != "crasher" {
+			t.Errorf("expect restart intensity error, got %v", err)
+		}
+	case <-time.After(time.Second * 3):
+		t.Fatal("supervisor should fail after too many restarts")
+	}
+	if temporary.Restarts() != 0 {
+		t.Error("temporary child should not be restarted")
+	}
+}
@@ -0,0 +1,75 @@
+package task
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/utils"
+	"time"
+)
+
+var TaskDroppedError = errors.New("任务执行器关闭时任务被丢弃")
+
+const (
+	drainAll = iota
+	drainTimeout
+	drainDiscard
+)
+
+// DrainPolicy 决定任务执行器关闭时如何处理队列中剩余的等待执行的任务。剩余的任务在执行
+// 器的运行协程中按照优先级依次处理，相同键的任务仍然按照提交的顺序处理。尚未到期的定时
+// 任务在执行器关闭时直接取消，不经过此策略处理
+type DrainPolicy struct {
+	mode      int
+	timeout   time.Duration
+	onDropped func(task Task)
+}
+
+// DrainAll 在执行器关闭时执行所有剩余的任务，执行任务时没有中断器，此为任务执行器默认
+// 的关闭策略
+func DrainAll() DrainPolicy {
+	return DrainPolicy{mode: drainAll}
+}
+
+// DrainTimeout 在执行器关闭时执行剩余的任务，超过 timeout 后通过中断器中断正在执行的
+// 任务，并丢弃还未执行的任务。不响应中断器的任务无法被中断，被丢弃的任务通过 onDropped
+// 回调报告
+func DrainTimeout(timeout time.Duration, onDropped func(task Task)) DrainPolicy {
+	return DrainPolicy{mode: drainTimeout, timeout: timeout, onDropped: onDropped}
+}
+
+// DiscardPending 在执行器关闭时丢弃所有剩余的任务，被丢弃的任务通过 onDropped 回调报告
+func DiscardPending(onDropped func(task Task)) DrainPolicy {
+	return DrainPolicy{mode: drainDiscard, onDropped: onDropped}
+}
+
+// drop 丢弃任务，等待任务执行结果的任务的错误为 TaskDroppedError
+func (e *TaskExecutor) drop(task Task) {
+	e.dropped.Add(1)
+	discard(task, TaskDroppedError)
+	if onDropped := e.drainPolicy.onDropped; onDropped != nil {
+		onDropped(task)
+	}
+}
+
+// drain 使用关闭策略处理队列关闭后剩余的任务
+func (e *TaskExecutor) drain(q *taskQueue, remain []*queuedTask) {
+	policy := e.drainPolicy
+	var interrupter chan struct{}
+	var deadline time.Time
+	if policy.mode == drainTimeout {
+		interrupter = make(chan struct{}, 1)
+		deadline = time.Now().Add(policy.timeout)
+		timer := time.AfterFunc(policy.timeout, func() { utils.ChanTryPush(interrupter, struct{}{}) })
+		defer timer.Stop()
+	}
+	for _, t := range remain {
+		switch {
+		case policy.mode == drainDiscard:
+			e.drop(t.task)
+		case policy.mode == drainTimeout && !time.Now().Before(deadline):
+			e.drop(t.task)
+		default:
+			q.execute(t.task, interrupter)
+		}
+		q.done(t)
+	}
+}
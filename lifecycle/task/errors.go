@@ -0,0 +1,34 @@
+package task
+
+import (
+	"fmt"
+	"gitee.com/sy_183/common/errors"
+)
+
+var TaskCanceledError = errors.New("任务已被取消")
+
+var WaitTimeoutError = errors.New("等待任务结果超时")
+
+// PanicError 为任务执行时发生 panic 产生的错误，Stack 为发生 panic 时的调用栈
+type PanicError struct {
+	Value any
+	Stack []byte
+}
+
+func NewPanicError(value any, stack []byte) *PanicError {
+	return &PanicError{Value: value, Stack: stack}
+}
+
+func (e *PanicError) Error() string {
+	if e == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("任务执行时发生panic: %v", e.Value)
+}
+
+func (e *PanicError) Unwrap() error {
+	if err, is := e.Value.(error); is {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package task
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/lifecycle"
+	"runtime/debug"
+	"sync/atomic"
+	"time"
+)
+
+// Result 为有返回值的任务执行的结果
+type Result[V any] struct {
+	Value V
+	Err   error
+}
+
+const (
+	futureTaskPending = iota
+	futureTaskRunning
+	futureTaskDone
+)
+
+// FutureTask 为有返回值的任务，同时作为获取任务结果的 lifecycle.WaitableFuture。任务
+// 可以使用 WithKey 和 WithPriority 包装后提交，也可以作为定时任务提交。任务执行时发生的
+// panic 被转换为 PanicError 作为任务的错误，不会导致执行任务的工作协程退出
+type FutureTask[V any] struct {
+	fn     func(interrupter chan struct{}) (V, error)
+	state  atomic.Int32
+	result Result[V]
+	done   chan struct{}
+}
+
+// NewFutureTask 创建一个有返回值的任务，如果任务返回的错误为 lifecycle.InterruptedError，
+// 则任务被认为是中断的
+func NewFutureTask[V any](fn func(interrupter chan struct{}) (V, error)) *FutureTask[V] {
+	return &FutureTask[V]{fn: fn, done: make(chan struct{})}
+}
+
+// NewFutureFunc 创建一个不响应中断的有返回值的任务
+func NewFutureFunc[V any](fn func() (V, error)) *FutureTask[V] {
+	return NewFutureTask(func(chan struct{}) (V, error) { return fn() })
+}
+
+func (t *FutureTask[V]) call(interrupter chan struct{}) (result Result[V]) {
+	defer func() {
+		if e := recover(); e != nil {
+			result.Err = NewPanicError(e, debug.Stack())
+		}
+	}()
+	result.Value, result.Err = t.fn(interrupter)
+	return
+}
+
+func (t *FutureTask[V]) Do(interrupter chan struct{}) (interrupted bool) {
+	if !t.state.CompareAndSwap(futureTaskPending, futureTaskRunning) {
+		return false
+	}
+	result := t.call(interrupter)
+	t.result = result
+	t.state.Store(futureTaskDone)
+	close(t.done)
+	var interruptedErr *lifecycle.InterruptedError
+	return errors.As(result.Err, &interruptedErr)
+}
+
+// Complete 在任务执行之前设置任务的结果，此后任务不会被执行
+func (t *FutureTask[V]) Complete(result Result[V]) {
+	if t.state.CompareAndSwap(futureTaskPending, futureTaskRunning) {
+		t.result = result
+		t.state.Store(futureTaskDone)
+		close(t.done)
+	}
+}
+
+// Cancel 取消尚未开始执行的任务，任务的错误为 TaskCanceledError。已取消的任务仍然占用
+// 任务队列的位置，直到执行器取出此任务时将其丢弃
+func (t *FutureTask[V]) Cancel() bool {
+	if t.state.CompareAndSwap(futureTaskPending, futureTaskRunning) {
+		t.result = Result[V]{Err: TaskCanceledError}
+		t.state.Store(futureTaskDone)
+		close(t.done)
+		return true
+	}
+	return false
+}
+
+func (t *FutureTask[V]) discard(err error) {
+	t.Complete(Result[V]{Err: err})
+}
+
+// Done 返回一个在任务完成后关闭的通道
+func (t *FutureTask[V]) Done() <-chan struct{} {
+	return t.done
+}
+
+// Wait 等待任务完成并返回任务的结果
+func (t *FutureTask[V]) Wait() Result[V] {
+	<-t.done
+	return t.result
+}
+
+// Get 等待任务完成并返回任务的返回值和错误
+func (t *FutureTask[V]) Get() (V, error) {
+	result := t.Wait()
+	return result.Value, result.Err
+}
+
+// WaitTimeout 等待任务完成，如果超过指定的时间任务仍未完成，返回 WaitTimeoutError
+func (t *FutureTask[V]) WaitTimeout(timeout time.Duration) (V, error) {
+	select {
+	case <-t.done:
+		return t.result.Value, t.result.Err
+	default:
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-t.done:
+		return t.result.Value, t.result.Err
+	case <-timer.C:
+		var zero V
+		return zero, WaitTimeoutError
+	}
+}
+
+// WaitInterrupted 等待任务完成，如果等待期间收到中断信号，返回 lifecycle.InterruptedError，
+// 中断信号会被消费
+func (t *FutureTask[V]) WaitInterrupted(interrupter chan struct{}) (V, error) {
+	select {
+	case <-t.done:
+		return t.result.Value, t.result.Err
+	case <-interrupter:
+		var zero V
+		return zero, lifecycle.NewInterruptedError("任务", "等待")
+	}
+}
+
+// Submit 提交一个有返回值的任务，如果任务队列已满，则使用拒绝策略处理任务，被丢弃的
+// 任务的错误为 errors.TaskFull
+func Submit[V any](e *TaskExecutor, fn func(interrupter chan struct{}) (V, error)) (*FutureTask[V], error) {
+	t := NewFutureTask(fn)
+	if err := e.Async(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// SubmitFunc 提交一个不响应中断的有返回值的任务
+func SubmitFunc[V any](e *TaskExecutor, fn func() (V, error)) (*FutureTask[V], error) {
+	t := NewFutureFunc(fn)
+	if err := e.Async(t); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
@@ -0,0 +1,56 @@
+package task
+
+import (
+	"gitee.com/sy_183/common/option"
+	"time"
+)
+
+type workersSetter interface {
+	setWorkers(min, max int, idleTimeout time.Duration)
+}
+
+// WithWorkers 指定任务执行器使用固定数量的工作协程，多个工作协程执行任务时，只有相同键
+// 的任务保证按照提交的顺序执行
+func WithWorkers(workers int) option.AnyOption {
+	return option.AnyCustom(func(target any) {
+		if setter, is := target.(workersSetter); is {
+			setter.setWorkers(workers, workers, 0)
+		}
+	})
+}
+
+// WithElasticWorkers 指定任务执行器的工作协程数量在 min 与 max 之间伸缩，任务提交时如果
+// 没有空闲的工作协程则创建新的工作协程，工作协程空闲时间超过 idleTimeout 后退出
+func WithElasticWorkers(min, max int, idleTimeout time.Duration) option.AnyOption {
+	return option.AnyCustom(func(target any) {
+		if setter, is := target.(workersSetter); is {
+			setter.setWorkers(min, max, idleTimeout)
+		}
+	})
+}
+
+type rejectPolicySetter interface {
+	setRejectPolicy(policy RejectPolicy)
+}
+
+// WithRejectPolicy 指定任务队列已满时任务执行器的拒绝策略，默认为 BlockPolicy(0)
+func WithRejectPolicy(policy RejectPolicy) option.AnyOption {
+	return option.AnyCustom(func(target any) {
+		if setter, is := target.(rejectPolicySetter); is {
+			setter.setRejectPolicy(policy)
+		}
+	})
+}
+
+type drainPolicySetter interface {
+	setDrainPolicy(policy DrainPolicy)
+}
+
+// WithDrainPolicy 指定任务执行器关闭时处理剩余任务的策略，默认为 DrainAll()
+func WithDrainPolicy(policy DrainPolicy) option.AnyOption {
+	return option.AnyCustom(func(target any) {
+		if setter, is := target.(drainPolicySetter); is {
+			setter.setDrainPolicy(policy)
+		}
+	})
+}
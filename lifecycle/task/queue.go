@@ -0,0 +1,432 @@
+package task
+
+import (
+	"container/heap"
+	"gitee.com/sy_183/common/container"
+	"gitee.com/sy_183/common/utils"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type queuedTask struct {
+	task     Task
+	key      any
+	priority int
+	seq      uint64
+	epoch    uint64
+}
+
+// readyQueue 为可以立即执行的任务队列，优先级高的任务在前，相同优先级的任务按照加入队列
+// 的顺序排列
+type readyQueue []*queuedTask
+
+func (r readyQueue) Len() int { return len(r) }
+
+func (r readyQueue) Less(i, j int) bool {
+	if r[i].priority != r[j].priority {
+		return r[i].priority > r[j].priority
+	}
+	return r[i].seq < r[j].seq
+}
+
+func (r readyQueue) Swap(i, j int) { r[i], r[j] = r[j], r[i] }
+
+func (r *readyQueue) Push(x any) { *r = append(*r, x.(*queuedTask)) }
+
+func (r *readyQueue) Pop() any {
+	old := *r
+	t := old[len(old)-1]
+	old[len(old)-1] = nil
+	*r = old[:len(old)-1]
+	return t
+}
+
+// keyState 记录了同一个键的任务的执行状态，键存在于 keys 中说明有此键的任务正在排队
+// 或执行，后续此键的任务需要在 pending 中等待
+type keyState struct {
+	pending *container.LinkedList[*queuedTask]
+}
+
+type epochWaiter struct {
+	epoch uint64
+	done  chan struct{}
+}
+
+// taskQueue 为任务执行器一次运行期间使用的任务队列，多个工作协程共享此队列
+type taskQueue struct {
+	maxTask int
+
+	ready readyQueue
+	seq   uint64
+	keys  map[any]*keyState
+	size  int
+	// running 为正在执行的任务数量，size 与 running 的差为等待执行的任务数量
+	running   int
+	highWater *atomic.Int64
+	panicked  *atomic.Int64
+
+	// 尚未到期的定时任务，由 timer 在最早的任务到期时触发加入队列
+	delayed delayedQueue
+	timer   *time.Timer
+
+	closed   bool
+	closedCh chan struct{}
+	signal   chan struct{}
+	space    chan struct{}
+	// 任务返回中断后关闭，执行器收到后关闭自身
+	interrupted     chan struct{}
+	interruptedOnce sync.Once
+
+	// 用于 Wait 等待在其之前提交的任务全部执行完成
+	epoch       uint64
+	outstanding map[uint64]int
+	waiters     []epochWaiter
+
+	minWorkers   int
+	maxWorkers   int
+	idleTimeout  time.Duration
+	workers      int
+	idle         int
+	interrupters map[chan struct{}]struct{}
+	workerWaiter sync.WaitGroup
+
+	mu sync.Mutex
+}
+
+func newTaskQueue(maxTask, minWorkers, maxWorkers int, idleTimeout time.Duration, highWater, panicked *atomic.Int64) *taskQueue {
+	return &taskQueue{
+		maxTask:      maxTask,
+		highWater:    highWater,
+		panicked:     panicked,
+		keys:         make(map[any]*keyState),
+		closedCh:     make(chan struct{}),
+		signal:       make(chan struct{}, 1),
+		space:        make(chan struct{}, 1),
+		interrupted:  make(chan struct{}),
+		outstanding:  make(map[uint64]int),
+		minWorkers:   minWorkers,
+		maxWorkers:   maxWorkers,
+		idleTimeout:  idleTimeout,
+		interrupters: make(map[chan struct{}]struct{}),
+	}
+}
+
+// full 判断队列是否已满，调用时必须持有队列的锁。maxTask 为0时队列不缓存任务，只有存在
+// 空闲的工作协程或者还可以启动新的工作协程时才接受任务，与无缓冲的通道相同。maxTask 小于
+// 0时队列的长度没有限制
+func (q *taskQueue) full() bool {
+	switch {
+	case q.maxTask > 0:
+		return q.size >= q.maxTask
+	case q.maxTask == 0:
+		return q.size-q.running >= q.idle+q.maxWorkers-q.workers
+	}
+	return false
+}
+
+// pushReady 将任务加入可以立即执行的队列并通知工作协程，调用时必须持有队列的锁
+func (q *taskQueue) pushReady(t *queuedTask) {
+	q.seq++
+	t.seq = q.seq
+	heap.Push(&q.ready, t)
+	utils.ChanTryPush(q.signal, struct{}{})
+}
+
+// enqueue 将任务加入队列，调用时必须持有队列的锁
+func (q *taskQueue) enqueue(task Task, key any, priority int) {
+	t := &queuedTask{task: task, key: key, priority: priority, epoch: q.epoch}
+	q.size++
+	if queued := int64(q.size - q.running); queued > q.highWater.Load() {
+		q.highWater.Store(queued)
+	}
+	q.outstanding[t.epoch]++
+	if t.key != nil {
+		if ks := q.keys[t.key]; ks != nil {
+			ks.pending.AddTail(t)
+			return
+		}
+		q.keys[t.key] = &keyState{pending: container.NewLinkedList[*queuedTask]()}
+	}
+	q.pushReady(t)
+	if len(q.ready) > q.idle && q.workers < q.maxWorkers {
+		q.spawnWorker()
+	}
+}
+
+// push 将任务加入队列，如果队列已满，则最多等待 timeout 的时间直到队列有空闲的位置。
+// timeout 为0时一直等待，小于0时不等待
+func (q *taskQueue) push(task Task, timeout time.Duration) (ok bool, err error) {
+	var timeoutC <-chan time.Time
+	for {
+		q.mu.Lock()
+		if q.closed {
+			q.mu.Unlock()
+			return false, TaskExecutorClosedError
+		}
+		if !q.full() {
+			q.enqueue(task, taskKey(task), taskPriority(task))
+			if !q.full() {
+				utils.ChanTryPush(q.space, struct{}{})
+			}
+			q.mu.Unlock()
+			return true, nil
+		}
+		q.mu.Unlock()
+		if timeout < 0 {
+			return false, nil
+		}
+		if timeout > 0 && timeoutC == nil {
+			timer := time.NewTimer(timeout)
+			defer timer.Stop()
+			timeoutC = timer.C
+		}
+		select {
+		case <-q.space:
+		case <-q.closedCh:
+		case <-timeoutC:
+			return false, nil
+		}
+	}
+}
+
+// take 从队列中取出一个可以执行的任务，队列关闭后剩余的任务由关闭策略处理，不再由工作
+// 协程执行。调用时必须持有队列的锁
+func (q *taskQueue) take() *queuedTask {
+	if q.closed || len(q.ready) == 0 {
+		return nil
+	}
+	t := heap.Pop(&q.ready).(*queuedTask)
+	q.running++
+	if len(q.ready) > 0 {
+		utils.ChanTryPush(q.signal, struct{}{})
+	}
+	return t
+}
+
+// done 标记任务执行完成，如果有相同键的任务在等待，则将其加入可执行的队列
+func (q *taskQueue) done(t *queuedTask) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.running--
+	q.finish(t)
+}
+
+// finish 将任务移出队列，调用时必须持有队列的锁
+func (q *taskQueue) finish(t *queuedTask) {
+	q.size--
+	utils.ChanTryPush(q.space, struct{}{})
+	if t.key != nil {
+		if ks := q.keys[t.key]; ks != nil {
+			if entry := ks.pending.RemoveHead(); entry != nil {
+				q.pushReady(entry.Value())
+			} else {
+				delete(q.keys, t.key)
+			}
+		}
+	}
+	if q.outstanding[t.epoch]--; q.outstanding[t.epoch] == 0 {
+		delete(q.outstanding, t.epoch)
+		q.notifyWaiters()
+	}
+}
+
+// dropOldest 丢弃等待执行的任务中最早加入队列的任务，如果没有等待执行的任务，返回nil
+func (q *taskQueue) dropOldest() Task {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.ready) == 0 {
+		return nil
+	}
+	oldest := 0
+	for i, t := range q.ready {
+		if t.seq < q.ready[oldest].seq {
+			oldest = i
+		}
+	}
+	t := heap.Remove(&q.ready, oldest).(*queuedTask)
+	q.finish(t)
+	return t.task
+}
+
+// settled 判断指定代之前提交的任务是否全部执行完成，调用时必须持有队列的锁
+func (q *taskQueue) settled(epoch uint64) bool {
+	for e := range q.outstanding {
+		if e <= epoch {
+			return false
+		}
+	}
+	return true
+}
+
+func (q *taskQueue) notifyWaiters() {
+	waiters := q.waiters[:0]
+	for _, waiter := range q.waiters {
+		if q.settled(waiter.epoch) {
+			close(waiter.done)
+		} else {
+			waiters = append(waiters, waiter)
+		}
+	}
+	q.waiters = waiters
+}
+
+// wait 返回一个在当前已经提交的任务全部执行完成后关闭的通道
+func (q *taskQueue) wait() (<-chan struct{}, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return nil, TaskExecutorClosedError
+	}
+	waiter := epochWaiter{epoch: q.epoch, done: make(chan struct{})}
+	q.epoch++
+	if q.settled(waiter.epoch) {
+		close(waiter.done)
+	} else {
+		q.waiters = append(q.waiters, waiter)
+	}
+	return waiter.done, nil
+}
+
+// spawnWorker 启动一个工作协程，调用时必须持有队列的锁
+func (q *taskQueue) spawnWorker() {
+	interrupter := make(chan struct{}, 1)
+	q.interrupters[interrupter] = struct{}{}
+	q.workers++
+	q.workerWaiter.Add(1)
+	go q.work(interrupter)
+}
+
+// retire 在工作协程空闲超时后判断是否可以退出，工作协程的数量不会少于最小值
+func (q *taskQueue) retire(interrupter chan struct{}) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.workers <= q.minWorkers || len(q.ready) > 0 {
+		return false
+	}
+	q.leave(interrupter, true)
+	return true
+}
+
+// leave 释放工作协程占用的位置，调用时必须持有队列的锁，工作协程的每个退出路径都需要
+// 调用，否则执行器认为工作协程仍然存在而不再启动新的工作协程
+func (q *taskQueue) leave(interrupter chan struct{}, idle bool) {
+	q.workers--
+	if idle {
+		q.idle--
+	}
+	delete(q.interrupters, interrupter)
+}
+
+// exit 在队列关闭或工作协程被中断时释放工作协程占用的位置
+func (q *taskQueue) exit(interrupter chan struct{}, idle bool) {
+	q.mu.Lock()
+	q.leave(interrupter, idle)
+	q.mu.Unlock()
+}
+
+// execute 执行任务，任务发生 panic 时恢复并记录，工作协程继续执行后续的任务，避免工作协程
+// 因为任务的 panic 而减少
+func (q *taskQueue) execute(task Task, interrupter chan struct{}) (interrupted bool) {
+	defer func() {
+		if e := recover(); e != nil {
+			q.panicked.Add(1)
+		}
+	}()
+	return task.Do(interrupter)
+}
+
+func (q *taskQueue) work(interrupter chan struct{}) {
+	defer q.workerWaiter.Done()
+	var idleTimer *time.Timer
+	var idleC <-chan time.Time
+	if q.idleTimeout > 0 && q.maxWorkers > q.minWorkers {
+		idleTimer = time.NewTimer(q.idleTimeout)
+		defer idleTimer.Stop()
+	}
+	idle := false
+	for {
+		q.mu.Lock()
+		if idle {
+			q.idle--
+			idle = false
+		}
+		t := q.take()
+		if t == nil {
+			q.idle++
+			idle = true
+			// 工作协程空闲后可以接受新的任务，maxTask 为0时等待提交的任务需要被唤醒
+			utils.ChanTryPush(q.space, struct{}{})
+		}
+		q.mu.Unlock()
+
+		if t != nil {
+			if q.execute(t.task, interrupter) {
+				// 任务返回中断时停止执行器，与之前基于通道的实现相同，剩余的任务在执行器
+				// 关闭时由关闭策略处理
+				q.done(t)
+				q.exit(interrupter, false)
+				q.interruptedOnce.Do(func() { close(q.interrupted) })
+				return
+			}
+			q.done(t)
+			continue
+		}
+
+		if idleTimer != nil {
+			if !idleTimer.Stop() {
+				select {
+				case <-idleTimer.C:
+				default:
+				}
+			}
+			idleTimer.Reset(q.idleTimeout)
+			idleC = idleTimer.C
+		}
+		select {
+		case <-q.signal:
+		case <-idleC:
+			if q.retire(interrupter) {
+				return
+			}
+		case <-q.closedCh:
+			q.exit(interrupter, true)
+			return
+		case <-interrupter:
+			q.exit(interrupter, true)
+			return
+		}
+	}
+}
+
+// close 关闭队列，不再接受新的任务，中断正在执行的任务并等待所有工作协程退出，返回队
+// 列中剩余未执行的任务。尚未到期的定时任务被取消
+func (q *taskQueue) close() (remain []*queuedTask) {
+	q.mu.Lock()
+	q.closed = true
+	close(q.closedCh)
+	q.clearDelayed()
+	for interrupter := range q.interrupters {
+		utils.ChanTryPush(interrupter, struct{}{})
+	}
+	q.mu.Unlock()
+	q.workerWaiter.Wait()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.ready) > 0 {
+		t := heap.Pop(&q.ready).(*queuedTask)
+		remain = append(remain, t)
+		if t.key != nil {
+			if ks := q.keys[t.key]; ks != nil {
+				for pending := ks.pending.HeadEntry(); pending != nil; pending = pending.Next() {
+					remain = append(remain, pending.Value())
+				}
+			}
+		}
+	}
+	// 剩余的任务不再按照键调度，由调用者执行并在执行完成后调用 done
+	q.keys = make(map[any]*keyState)
+	q.running += len(remain)
+	return
+}
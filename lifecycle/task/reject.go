@@ -0,0 +1,150 @@
+package task
+
+import (
+	"gitee.com/sy_183/common/errors"
+	"time"
+)
+
+// RejectPolicy 决定任务队列已满时如何处理通过 Async 或 Sync 提交的任务。Reject 返回的
+// 错误作为提交任务的错误，返回nil表示任务已经被处理
+type RejectPolicy interface {
+	Reject(e *TaskExecutor, task Task) error
+}
+
+type RejectPolicyFunc func(e *TaskExecutor, task Task) error
+
+func (f RejectPolicyFunc) Reject(e *TaskExecutor, task Task) error {
+	return f(e, task)
+}
+
+// discardable 由等待任务执行结果的任务实现，任务被丢弃时使用丢弃的原因完成任务，使等待
+// 任务结果的协程不会一直阻塞
+type discardable interface {
+	discard(err error)
+}
+
+// discard 使用丢弃的原因完成任务包装链中的每一个 discardable，例如 Sync 提交的
+// FutureTask 同时有 Sync 和 FutureTask 两层在等待结果
+func discard(task Task, err error) {
+	for {
+		if d, is := task.(discardable); is {
+			d.discard(err)
+		}
+		wrapper, is := task.(interface{ unwrap() Task })
+		if !is {
+			return
+		}
+		task = wrapper.unwrap()
+	}
+}
+
+type blockPolicy struct {
+	timeout time.Duration
+}
+
+// BlockPolicy 等待任务队列有空闲的位置，最多等待 timeout 的时间，超时后返回
+// errors.TaskFull。timeout 为0时一直等待，此为任务执行器默认的拒绝策略
+func BlockPolicy(timeout time.Duration) RejectPolicy {
+	return blockPolicy{timeout: timeout}
+}
+
+func (p blockPolicy) Reject(e *TaskExecutor, task Task) error {
+	q := e.loadQueue()
+	if q == nil {
+		return TaskExecutorClosedError
+	}
+	if ok, err := q.push(task, p.timeout); err != nil || ok {
+		return err
+	}
+	return e.abort(task)
+}
+
+type abortPolicy struct{}
+
+// AbortPolicy 拒绝任务并返回 errors.TaskFull
+func AbortPolicy() RejectPolicy {
+	return abortPolicy{}
+}
+
+func (abortPolicy) Reject(e *TaskExecutor, task Task) error {
+	return e.abort(task)
+}
+
+type discardPolicy struct{}
+
+// DiscardPolicy 丢弃新提交的任务，提交任务不返回错误
+func DiscardPolicy() RejectPolicy {
+	return discardPolicy{}
+}
+
+func (discardPolicy) Reject(e *TaskExecutor, task Task) error {
+	e.abort(task)
+	return nil
+}
+
+type discardOldestPolicy struct{}
+
+// DiscardOldestPolicy 丢弃等待执行的任务中最早提交的任务，然后重新提交任务。如果所有的
+// 任务都在执行中，则拒绝任务并返回 errors.TaskFull
+func DiscardOldestPolicy() RejectPolicy {
+	return discardOldestPolicy{}
+}
+
+func (discardOldestPolicy) Reject(e *TaskExecutor, task Task) error {
+	q := e.loadQueue()
+	if q == nil {
+		return TaskExecutorClosedError
+	}
+	for {
+		oldest := q.dropOldest()
+		if oldest == nil {
+			return e.abort(task)
+		}
+		e.abort(oldest)
+		if ok, err := q.push(task, -1); err != nil || ok {
+			return err
+		}
+	}
+}
+
+type callerRunsPolicy struct{}
+
+// CallerRunsPolicy 在提交任务的协程中直接执行任务，此时任务不受键的顺序约束，也无法被
+// 任务执行器中断。任务没有被拒绝，在统计信息中单独记录为 CallerRuns
+func CallerRunsPolicy() RejectPolicy {
+	return callerRunsPolicy{}
+}
+
+func (callerRunsPolicy) Reject(e *TaskExecutor, task Task) error {
+	e.callerRuns.Add(1)
+	task.Do(nil)
+	return nil
+}
+
+// abort 记录一次拒绝并丢弃任务，返回 errors.TaskFull
+func (e *TaskExecutor) abort(task Task) error {
+	e.rejected.Add(1)
+	err := &errors.TaskFull{Action: "任务执行器", MaxTask: e.maxTask}
+	discard(task, err)
+	return err
+}
+
+// Stats 为任务执行器的统计信息
+type Stats struct {
+	// Queued 为等待执行的任务数量
+	Queued int
+	// Running 为正在执行的任务数量
+	Running int
+	// Delayed 为尚未到期的定时任务数量
+	Delayed int
+	// HighWater 为等待执行的任务数量的最大值
+	HighWater int64
+	// Rejected 为因为任务队列已满而被拒绝或丢弃的任务数量
+	Rejected int64
+	// CallerRuns 为因为任务队列已满而由 CallerRunsPolicy 在提交任务的协程中执行的任务数量
+	CallerRuns int64
+	// Dropped 为执行器关闭时被关闭策略丢弃的任务数量
+	Dropped int64
+	// Panicked 为执行时发生 panic 的任务数量
+	Panicked int64
+}
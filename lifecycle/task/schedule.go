@@ -0,0 +1,206 @@
+package task
+
+import (
+	"container/heap"
+	"gitee.com/sy_183/common/errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var InvalidPeriodError = errors.New("周期任务的周期必须大于0")
+
+// Scheduled 为提交到任务执行器的定时任务或周期任务的句柄，用于取消任务
+type Scheduled struct {
+	q        *taskQueue
+	task     Task
+	key      any
+	priority int
+
+	next      time.Time
+	period    time.Duration
+	fixedRate bool
+	index     int
+
+	runs     atomic.Int64
+	canceled atomic.Bool
+	done     chan struct{}
+	doneOnce sync.Once
+}
+
+func newScheduled(q *taskQueue, task Task, next time.Time, period time.Duration, fixedRate bool) *Scheduled {
+	return &Scheduled{
+		q:         q,
+		task:      task,
+		key:       taskKey(task),
+		priority:  taskPriority(task),
+		next:      next,
+		period:    period,
+		fixedRate: fixedRate,
+		index:     -1,
+		done:      make(chan struct{}),
+	}
+}
+
+func (s *Scheduled) finish() {
+	s.doneOnce.Do(func() { close(s.done) })
+}
+
+// Cancel 取消任务，如果任务正在执行，则等待本次执行完成后不再执行。如果任务已经执行完成
+// 或已经被取消，返回false
+func (s *Scheduled) Cancel() bool {
+	select {
+	case <-s.done:
+		return false
+	default:
+	}
+	if !s.canceled.CompareAndSwap(false, true) {
+		return false
+	}
+	q := s.q
+	q.mu.Lock()
+	if s.index >= 0 {
+		heap.Remove(&q.delayed, s.index)
+		q.resetTimer()
+		q.mu.Unlock()
+		s.finish()
+		return true
+	}
+	q.mu.Unlock()
+	return true
+}
+
+// Canceled 返回任务是否已经被取消
+func (s *Scheduled) Canceled() bool {
+	return s.canceled.Load()
+}
+
+// Runs 返回任务已经执行的次数
+func (s *Scheduled) Runs() int64 {
+	return s.runs.Load()
+}
+
+// Done 返回一个在任务不再执行后关闭的通道，单次执行的任务在执行完成后关闭，周期任务在被
+// 取消或任务执行器关闭后关闭
+func (s *Scheduled) Done() <-chan struct{} {
+	return s.done
+}
+
+func (s *Scheduled) run(interrupter chan struct{}) (interrupted bool) {
+	if s.canceled.Load() {
+		s.finish()
+		return false
+	}
+	defer func() {
+		if e := recover(); e != nil {
+			// 任务发生 panic 时不再执行，继续 panic 由工作协程恢复并记录
+			s.runs.Add(1)
+			s.finish()
+			panic(e)
+		}
+	}()
+	interrupted = s.task.Do(interrupter)
+	s.runs.Add(1)
+	if s.period <= 0 || interrupted {
+		s.finish()
+		return
+	}
+	now := time.Now()
+	if s.fixedRate {
+		// 固定频率的任务按照首次执行的时间对齐，执行时间超过周期时跳过错过的执行
+		s.next = s.next.Add(s.period)
+		if s.next.Before(now) {
+			s.next = s.next.Add((now.Sub(s.next)/s.period + 1) * s.period)
+		}
+	} else {
+		s.next = now.Add(s.period)
+	}
+	if !s.q.schedule(s) {
+		s.finish()
+	}
+	return
+}
+
+// delayedQueue 为尚未到期的定时任务队列，最早到期的任务在前
+type delayedQueue []*Scheduled
+
+func (d delayedQueue) Len() int { return len(d) }
+
+func (d delayedQueue) Less(i, j int) bool { return d[i].next.Before(d[j].next) }
+
+func (d delayedQueue) Swap(i, j int) {
+	d[i], d[j] = d[j], d[i]
+	d[i].index, d[j].index = i, j
+}
+
+func (d *delayedQueue) Push(x any) {
+	s := x.(*Scheduled)
+	s.index = len(*d)
+	*d = append(*d, s)
+}
+
+func (d *delayedQueue) Pop() any {
+	old := *d
+	s := old[len(old)-1]
+	old[len(old)-1] = nil
+	s.index = -1
+	*d = old[:len(old)-1]
+	return s
+}
+
+// resetTimer 将定时器设置为最早到期的定时任务的时间，调用时必须持有队列的锁
+func (q *taskQueue) resetTimer() {
+	if len(q.delayed) == 0 {
+		if q.timer != nil {
+			q.timer.Stop()
+		}
+		return
+	}
+	d := time.Until(q.delayed[0].next)
+	if q.timer == nil {
+		q.timer = time.AfterFunc(d, q.fire)
+	} else {
+		q.timer.Reset(d)
+	}
+}
+
+// schedule 将定时任务加入队列，如果队列已经关闭或任务已经被取消，返回false
+func (q *taskQueue) schedule(s *Scheduled) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed || s.canceled.Load() {
+		return false
+	}
+	heap.Push(&q.delayed, s)
+	if s.index == 0 {
+		q.resetTimer()
+	}
+	return true
+}
+
+// fire 将所有到期的定时任务加入可以立即执行的队列，到期的任务不受队列最大任务数量的限制
+func (q *taskQueue) fire() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.closed {
+		return
+	}
+	now := time.Now()
+	for len(q.delayed) > 0 && !q.delayed[0].next.After(now) {
+		s := heap.Pop(&q.delayed).(*Scheduled)
+		q.enqueue(Interrupted(s.run), s.key, s.priority)
+	}
+	q.resetTimer()
+}
+
+// clearDelayed 取消所有尚未到期的定时任务，调用时必须持有队列的锁
+func (q *taskQueue) clearDelayed() {
+	if q.timer != nil {
+		q.timer.Stop()
+	}
+	for _, s := range q.delayed {
+		s.index = -1
+		s.finish()
+	}
+	q.delayed = nil
+}
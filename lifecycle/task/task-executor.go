@@ -3,57 +3,103 @@ package task
 import (
 	"gitee.com/sy_183/common/errors"
 	"gitee.com/sy_183/common/lifecycle"
-	"gitee.com/sy_183/common/utils"
+	"gitee.com/sy_183/common/lock"
+	"gitee.com/sy_183/common/option"
+	"runtime/debug"
 	"sync"
 	"sync/atomic"
+	"time"
 )
 
 var TaskExecutorClosedError = errors.New("任务执行器已关闭")
 
+// TaskExecutor 为任务执行器，任务按照提交的顺序加入队列，由一个或多个工作协程执行。默认
+// 只有一个工作协程，此时任务按照提交的顺序依次执行。实现了 KeyedTask 的任务中，相同键的
+// 任务总是按照提交的顺序依次执行。实现了 PriorityTask 的任务优先于优先级低的任务执行，定
+// 时任务和周期任务在到期后加入队列
 type TaskExecutor struct {
 	lifecycle.Lifecycle
 	maxTask int
-	ch      atomic.Pointer[chan Task]
+
+	minWorkers  int
+	maxWorkers  int
+	idleTimeout time.Duration
+
+	rejectPolicy RejectPolicy
+	rejected     atomic.Int64
+	callerRuns   atomic.Int64
+	highWater    atomic.Int64
+	panicked     atomic.Int64
+
+	drainPolicy DrainPolicy
+	dropped     atomic.Int64
+
+	queue atomic.Pointer[taskQueue]
 }
 
-func NewTaskExecutor(maxTask int) *TaskExecutor {
-	executor := &TaskExecutor{maxTask: maxTask}
+// Unbounded 作为 NewTaskExecutor 的 maxTask 参数时任务队列的长度没有限制
+const Unbounded = -1
+
+// NewTaskExecutor 创建任务执行器，maxTask 为任务队列中最多等待执行的任务数量，队列已满
+// 时使用拒绝策略处理新提交的任务。maxTask 为0时任务队列不缓存任务，与无缓冲的通道相同，
+// 只有存在空闲的工作协程时才能提交任务，maxTask 为 Unbounded(小于0)时任务队列的长度没有
+// 限制。任务执行返回中断时任务执行器关闭
+func NewTaskExecutor(maxTask int, options ...option.AnyOption) *TaskExecutor {
+	executor := &TaskExecutor{maxTask: maxTask, minWorkers: 1, maxWorkers: 1, rejectPolicy: BlockPolicy(0)}
+	for _, opt := range options {
+		opt.Apply(executor)
+	}
 	executor.Lifecycle = lifecycle.NewWithInterruptedRun(executor.start, executor.run)
 	return executor
 }
 
-func (e *TaskExecutor) loadChannel() chan Task {
-	if ch := e.ch.Load(); ch != nil {
-		return *ch
+func (e *TaskExecutor) setWorkers(min, max int, idleTimeout time.Duration) {
+	if max < 1 {
+		max = 1
 	}
-	return nil
+	if min < 0 {
+		min = 0
+	} else if min > max {
+		min = max
+	}
+	e.minWorkers, e.maxWorkers, e.idleTimeout = min, max, idleTimeout
+}
+
+func (e *TaskExecutor) setRejectPolicy(policy RejectPolicy) {
+	if policy == nil {
+		policy = BlockPolicy(0)
+	}
+	e.rejectPolicy = policy
+}
+
+func (e *TaskExecutor) setDrainPolicy(policy DrainPolicy) {
+	e.drainPolicy = policy
+}
+
+func (e *TaskExecutor) loadQueue() *taskQueue {
+	return e.queue.Load()
 }
 
 func (e *TaskExecutor) start(_ lifecycle.Lifecycle, interrupter chan struct{}) error {
-	ch := make(chan Task, e.maxTask)
-	e.ch.Store(&ch)
+	q := newTaskQueue(e.maxTask, e.minWorkers, e.maxWorkers, e.idleTimeout, &e.highWater, &e.panicked)
+	q.mu.Lock()
+	for i := 0; i < e.minWorkers; i++ {
+		q.spawnWorker()
+	}
+	q.mu.Unlock()
+	e.queue.Store(q)
 	return nil
 }
 
 func (e *TaskExecutor) run(_ lifecycle.Lifecycle, interrupter chan struct{}) error {
-	ch := e.loadChannel()
-	defer func() {
-		e.ch.Store(nil)
-		close(ch)
-		for task := range ch {
-			task.Do(nil)
-		}
-	}()
-	for {
-		select {
-		case task := <-ch:
-			if task.Do(interrupter) {
-				return nil
-			}
-		case <-interrupter:
-			return nil
-		}
+	q := e.loadQueue()
+	select {
+	case <-interrupter:
+	case <-q.interrupted:
 	}
+	e.queue.Store(nil)
+	e.drain(q, q.close())
+	return nil
 }
 
 func (e *TaskExecutor) StartFunc() lifecycle.InterruptedStartFunc {
@@ -64,58 +110,150 @@ func (e *TaskExecutor) RunFunc() lifecycle.InterruptedRunFunc {
 	return e.run
 }
 
-func (e *TaskExecutor) notPanicPush(ch chan Task, task Task) (err error) {
-	defer func() {
-		if _, is := recover().(error); is {
-			err = TaskExecutorClosedError
-		}
-	}()
-	ch <- task
-	return nil
+// Async 提交任务，如果任务队列已满，则使用拒绝策略处理任务
+func (e *TaskExecutor) Async(task Task) error {
+	q := e.loadQueue()
+	if q == nil {
+		return TaskExecutorClosedError
+	}
+	if ok, err := q.push(task, -1); err != nil || ok {
+		return err
+	}
+	return e.rejectPolicy.Reject(e, task)
 }
 
-func (e *TaskExecutor) notPanicTryPush(ch chan Task, task Task) (ok bool, err error) {
+type syncTask struct {
+	task   Task
+	waiter sync.WaitGroup
+	err    error
+}
+
+func (t *syncTask) Do(interrupter chan struct{}) (interrupted bool) {
+	defer t.waiter.Done()
 	defer func() {
-		if _, is := recover().(error); is {
-			err = TaskExecutorClosedError
+		if e := recover(); e != nil {
+			// 任务的 panic 作为 Sync 的返回值，继续 panic 由工作协程恢复并记录
+			t.err = NewPanicError(e, debug.Stack())
+			panic(e)
 		}
 	}()
-	return utils.ChanTryPush(ch, task), nil
+	return t.task.Do(interrupter)
 }
 
-func (e *TaskExecutor) Async(task Task) error {
-	ch := e.loadChannel()
-	if ch == nil {
-		return TaskExecutorClosedError
-	}
-	return e.notPanicPush(ch, task)
+func (t *syncTask) discard(err error) {
+	t.err = err
+	t.waiter.Done()
+}
+
+func (t *syncTask) unwrap() Task {
+	return t.task
 }
 
+// Sync 提交任务并等待任务执行完成，如果任务被拒绝策略丢弃，返回丢弃的原因，如果任务执行
+// 时发生 panic，返回 *PanicError
 func (e *TaskExecutor) Sync(task Task) error {
-	ch := e.loadChannel()
-	if ch == nil {
-		return TaskExecutorClosedError
-	}
-	waiter := sync.WaitGroup{}
-	waiter.Add(1)
-	if err := e.notPanicPush(ch, Interrupted(func(interrupter chan struct{}) (interrupted bool) {
-		defer waiter.Done()
-		return task.Do(interrupter)
-	})); err != nil {
+	st := &syncTask{task: task}
+	st.waiter.Add(1)
+	if err := e.Async(inherit(task, st)); err != nil {
 		return err
 	}
-	waiter.Wait()
-	return nil
+	st.waiter.Wait()
+	return st.err
 }
 
+// Try 尝试提交任务，如果任务队列已满，则不提交任务并返回false，此时任务被记录为拒绝
 func (e *TaskExecutor) Try(task Task) (ok bool, err error) {
-	ch := e.loadChannel()
-	if ch == nil {
+	q := e.loadQueue()
+	if q == nil {
 		return false, TaskExecutorClosedError
 	}
-	return e.notPanicTryPush(ch, task)
+	if ok, err = q.push(task, -1); err == nil && !ok {
+		e.rejected.Add(1)
+	}
+	return
 }
 
+// Stats 返回任务执行器的统计信息，等待执行的任务数量的最大值以及各类任务的计数在执行器
+// 重启后继续累计
+func (e *TaskExecutor) Stats() Stats {
+	stats := Stats{
+		HighWater:  e.highWater.Load(),
+		Rejected:   e.rejected.Load(),
+		CallerRuns: e.callerRuns.Load(),
+		Dropped:    e.dropped.Load(),
+		Panicked:   e.panicked.Load(),
+	}
+	if q := e.loadQueue(); q != nil {
+		q.mu.Lock()
+		stats.Queued = q.size - q.running
+		stats.Running = q.running
+		stats.Delayed = len(q.delayed)
+		q.mu.Unlock()
+	}
+	return stats
+}
+
+// Wait 等待在此之前提交的所有任务执行完成，不包括尚未到期的定时任务
 func (e *TaskExecutor) Wait() error {
-	return e.Sync(Nop())
+	q := e.loadQueue()
+	if q == nil {
+		return TaskExecutorClosedError
+	}
+	done, err := q.wait()
+	if err != nil {
+		return err
+	}
+	<-done
+	return nil
+}
+
+// Workers 返回当前工作协程的数量，任务执行器未运行时返回0
+func (e *TaskExecutor) Workers() int {
+	q := e.loadQueue()
+	if q == nil {
+		return 0
+	}
+	return lock.LockGet(&q.mu, func() int { return q.workers })
+}
+
+func (e *TaskExecutor) schedule(task Task, next time.Time, period time.Duration, fixedRate bool) (*Scheduled, error) {
+	q := e.loadQueue()
+	if q == nil {
+		return nil, TaskExecutorClosedError
+	}
+	s := newScheduled(q, task, next, period, fixedRate)
+	if !q.schedule(s) {
+		return nil, TaskExecutorClosedError
+	}
+	return s, nil
+}
+
+// Schedule 提交一个在 delay 之后执行的任务，任务到期后按照优先级和键与其他任务一起调度，
+// 执行器关闭时尚未到期的任务不再执行
+func (e *TaskExecutor) Schedule(task Task, delay time.Duration) (*Scheduled, error) {
+	return e.schedule(task, time.Now().Add(delay), 0, false)
+}
+
+// ScheduleAt 提交一个在指定时间执行的任务
+func (e *TaskExecutor) ScheduleAt(task Task, at time.Time) (*Scheduled, error) {
+	return e.schedule(task, at, 0, false)
+}
+
+// ScheduleAtFixedRate 提交一个以固定频率执行的周期任务，首次执行在 initialDelay 之后，
+// 之后每隔 period 执行一次。同一个周期任务不会并行执行，如果执行时间超过了周期，则跳过
+// 错过的执行
+func (e *TaskExecutor) ScheduleAtFixedRate(task Task, initialDelay, period time.Duration) (*Scheduled, error) {
+	if period <= 0 {
+		return nil, InvalidPeriodError
+	}
+	return e.schedule(task, time.Now().Add(initialDelay), period, true)
+}
+
+// ScheduleWithFixedDelay 提交一个以固定间隔执行的周期任务，首次执行在 initialDelay 之后，
+// 之后每次执行完成后间隔 delay 再次执行
+func (e *TaskExecutor) ScheduleWithFixedDelay(task Task, initialDelay, delay time.Duration) (*Scheduled, error) {
+	if delay <= 0 {
+		return nil, InvalidPeriodError
+	}
+	return e.schedule(task, time.Now().Add(initialDelay), delay, false)
 }
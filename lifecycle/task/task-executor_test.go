@@ -0,0 +1,466 @@
+package task
+
+import (
+	"fmt"
+	"gitee.com/sy_183/common/errors"
+	"gitee.com/sy_183/common/lifecycle"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTaskExecutorWorkers(t *testing.T) {
+	executor := NewTaskExecutor(16, WithWorkers(4))
+	if err := executor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer executor.Shutdown()
+
+	var running, maxRunning atomic.Int64
+	begin := time.Now()
+	for i := 0; i < 8; i++ {
+		if err := executor.Async(Func(func() {
+			if n := running.Add(1); n > maxRunning.Load() {
+				maxRunning.Store(n)
+			}
+			time.Sleep(time.Millisecond * 50)
+			running.Add(-1)
+		})); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := executor.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed > time.Millisecond*190 {
+		t.Errorf("tasks should run in parallel, elapsed %s", elapsed)
+	}
+	if maxRunning.Load() != 4 {
+		t.Errorf("expect 4 tasks running in parallel, got %d", maxRunning.Load())
+	}
+}
+
+func TestTaskExecutorKeyed(t *testing.T) {
+	executor := NewTaskExecutor(Unbounded, WithElasticWorkers(0, 8, time.Millisecond*20))
+	if err := executor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer executor.Shutdown()
+
+	var mu sync.Mutex
+	orders := make(map[int][]int)
+	for i := 0; i < 50; i++ {
+		key, seq := i%3, i
+		if err := executor.Async(WithKey(key, Func(func() {
+			time.Sleep(time.Millisecond)
+			mu.Lock()
+			orders[key] = append(orders[key], seq)
+			mu.Unlock()
+		}))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := executor.Sync(Nop()); err != nil {
+		t.Fatal(err)
+	}
+	if err := executor.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	for key, order := range orders {
+		for i := 1; i < len(order); i++ {
+			if order[i] < order[i-1] {
+				t.Fatalf("tasks of key %d run out of order: %v", key, order)
+			}
+		}
+	}
+	time.Sleep(time.Millisecond * 100)
+	if workers := executor.Workers(); workers != 0 {
+		t.Errorf("idle workers should exit, %d workers remain", workers)
+	}
+}
+
+func TestTaskExecutorPriority(t *testing.T) {
+	executor := NewTaskExecutor(Unbounded)
+	if err := executor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer executor.Shutdown()
+
+	block := make(chan struct{})
+	executor.Async(Func(func() { <-block }))
+	var orders []int
+	for i, priority := range []int{0, 2, 1, 2, 0} {
+		seq := i
+		executor.Async(WithPriority(priority, Func(func() { orders = append(orders, seq) })))
+	}
+	close(block)
+	if err := executor.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if expect := []int{1, 3, 2, 0, 4}; fmt.Sprint(orders) != fmt.Sprint(expect) {
+		t.Errorf("expect run order %v, got %v", expect, orders)
+	}
+}
+
+func TestTaskExecutorSchedule(t *testing.T) {
+	executor := NewTaskExecutor(0, WithWorkers(2))
+	if err := executor.Start(); err != nil {
+		t.Fatal(err)
+	}
+
+	begin := time.Now()
+	var delayed time.Duration
+	once, err := executor.Schedule(Func(func() { delayed = time.Since(begin) }), time.Millisecond*50)
+	if err != nil {
+		t.Fatal(err)
+	}
+	canceled, _ := executor.Schedule(Func(func() { t.Error("canceled task should not run") }), time.Millisecond*50)
+	if !canceled.Cancel() {
+		t.Error("cancel a pending task should succeed")
+	}
+
+	var rateRuns, delayRuns atomic.Int64
+	rate, _ := executor.ScheduleAtFixedRate(Func(func() { rateRuns.Add(1) }), 0, time.Millisecond*20)
+	fixedDelay, _ := executor.ScheduleWithFixedDelay(Func(func() {
+		delayRuns.Add(1)
+		time.Sleep(time.Millisecond * 20)
+	}), 0, time.Millisecond*20)
+
+	<-once.Done()
+	if delayed < time.Millisecond*50 {
+		t.Errorf("task run too early, after %s", delayed)
+	}
+	if once.Cancel() {
+		t.Error("cancel a finished task should fail")
+	}
+
+	time.Sleep(time.Millisecond * 150)
+	rate.Cancel()
+	<-rate.Done()
+	if runs := rate.Runs(); runs < 7 || runs > 12 {
+		t.Errorf("fixed rate task expect about 11 runs, got %d", runs)
+	}
+	if runs := fixedDelay.Runs(); runs < 3 || runs > 7 {
+		t.Errorf("fixed delay task expect about 5 runs, got %d", runs)
+	}
+	runs := rateRuns.Load()
+	time.Sleep(time.Millisecond * 50)
+	if rateRuns.Load() != runs {
+		t.Error("canceled periodic task should not run")
+	}
+
+	executor.Shutdown()
+	select {
+	case <-fixedDelay.Done():
+	default:
+		t.Error("periodic task should be done after executor closed")
+	}
+	if _, err := executor.Schedule(Nop(), 0); err != TaskExecutorClosedError {
+		t.Errorf("expect closed error, got %v", err)
+	}
+}
+
+func TestFutureTask(t *testing.T) {
+	executor := NewTaskExecutor(Unbounded)
+	if err := executor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer executor.Shutdown()
+
+	value, err := SubmitFunc(executor, func() (int, error) { return 42, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, err := value.Get(); v != 42 || err != nil {
+		t.Errorf("expect (42, nil), got (%d, %v)", v, err)
+	}
+
+	panicked, _ := SubmitFunc(executor, func() (int, error) { panic("boom") })
+	var panicErr *PanicError
+	if _, err := panicked.Get(); !errors.As(err, &panicErr) || panicErr.Value != "boom" {
+		t.Errorf("expect panic error, got %v", err)
+	}
+
+	block := make(chan struct{})
+	blocking, _ := Submit(executor, func(interrupter chan struct{}) (struct{}, error) {
+		<-block
+		return struct{}{}, nil
+	})
+	queued, _ := SubmitFunc(executor, func() (int, error) {
+		t.Error("canceled task should not run")
+		return 0, nil
+	})
+	if _, err := blocking.WaitTimeout(time.Millisecond * 20); err != WaitTimeoutError {
+		t.Errorf("expect wait timeout error, got %v", err)
+	}
+	interrupter := make(chan struct{}, 1)
+	interrupter <- struct{}{}
+	var interruptedErr *lifecycle.InterruptedError
+	if _, err := blocking.WaitInterrupted(interrupter); !errors.As(err, &interruptedErr) {
+		t.Errorf("expect interrupted error, got %v", err)
+	}
+	if !queued.Cancel() {
+		t.Error("cancel a queued task should succeed")
+	}
+	close(block)
+	if _, err := queued.Get(); err != TaskCanceledError {
+		t.Errorf("expect canceled error, got %v", err)
+	}
+	if blocking.Cancel() {
+		t.Error("cancel a finished task should fail")
+	}
+	if err := executor.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskExecutorInterruptedTask(t *testing.T) {
+	executor := NewTaskExecutor(Unbounded, WithWorkers(1))
+	if err := executor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer executor.Shutdown()
+
+	// 任务自身返回中断时执行器关闭，剩余的任务由关闭策略处理
+	block := make(chan struct{})
+	interrupted, err := SubmitFunc(executor, func() (int, error) {
+		<-block
+		return 0, lifecycle.NewInterruptedError("任务", "测试")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	next, err := SubmitFunc(executor, func() (int, error) { return 1, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	close(block)
+	var interruptedErr *lifecycle.InterruptedError
+	if _, err := interrupted.Get(); !errors.As(err, &interruptedErr) {
+		t.Errorf("expect interrupted error, got %v", err)
+	}
+	select {
+	case <-executor.ClosedWaiter():
+	case <-time.After(time.Second):
+		t.Fatal("executor should be closed after interrupted task")
+	}
+	if v, err := next.WaitTimeout(time.Second); v != 1 || err != nil {
+		t.Errorf("pending task should be drained, got (%d, %v)", v, err)
+	}
+	if err := executor.Async(Nop()); err != TaskExecutorClosedError {
+		t.Errorf("expect closed error, got %v", err)
+	}
+}
+
+func TestTaskExecutorHandOff(t *testing.T) {
+	executor := NewTaskExecutor(0, WithWorkers(1))
+	if err := executor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer executor.Shutdown()
+
+	// maxTask 为0时任务直接交给空闲的工作协程，工作协程繁忙时不能提交任务
+	block := make(chan struct{})
+	started := make(chan struct{})
+	if err := executor.Async(Func(func() {
+		close(started)
+		<-block
+	})); err != nil {
+		t.Fatal(err)
+	}
+	<-started
+	if ok, err := executor.Try(Nop()); ok || err != nil {
+		t.Errorf("try should fail while the only worker is busy, got (%t, %v)", ok, err)
+	}
+	time.AfterFunc(time.Millisecond*30, func() { close(block) })
+	begin := time.Now()
+	if err := executor.Async(Nop()); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed < time.Millisecond*20 {
+		t.Errorf("async should block until the worker is free, elapsed %s", elapsed)
+	}
+	if err := executor.Wait(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestTaskExecutorPanic(t *testing.T) {
+	executor := NewTaskExecutor(Unbounded, WithWorkers(2))
+	if err := executor.Start(); err != nil {
+		t.Fatal(err)
+	}
+	defer executor.Shutdown()
+
+	// 普通任务发生 panic 时工作协程恢复并继续执行后续的任务
+	for i := 0; i < 4; i++ {
+		if err := executor.Async(Func(func() { panic("boom") })); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var panicErr *PanicError
+	if err := executor.Sync(Func(func() { panic("sync") })); !errors.As(err, &panicErr) || panicErr.Value != "sync" {
+		t.Errorf("expect panic error, got %v", err)
+	}
+	var ran atomic.Int32
+	for i := 0; i < 4; i++ {
+		if err := executor.Async(Func(func() { ran.Add(1) })); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := executor.Wait(); err != nil {
+		t.Fatal(err)
+	}
+	if n := ran.Load(); n != 4 {
+		t.Errorf("expect 4 tasks run after panic, got %d", n)
+	}
+	if n := executor.Workers(); n != 2 {
+		t.Errorf("expect 2 workers, got %d", n)
+	}
+	if n := executor.Stats().Panicked; n != 5 {
+		t.Errorf("expect 5 panicked tasks, got %d", n)
+	}
+}
+
+func TestTaskExecutorReject(t *testing.T) {
+	newExecutor := func(policy RejectPolicy) (*TaskExecutor, chan struct{}) {
+		executor := NewTaskExecutor(2, WithRejectPolicy(policy))
+		if err := executor.Start(); err != nil {
+			t.Fatal(err)
+		}
+		block := make(chan struct{})
+		started := make(chan struct{})
+		executor.Async(Func(func() {
+			close(started)
+			<-block
+		}))
+		<-started
+		return executor, block
+	}
+
+	executor, block := newExecutor(AbortPolicy())
+	queued, _ := SubmitFunc(executor, func() (int, error) { return 1, nil })
+	var taskFull *errors.TaskFull
+	if _, err := SubmitFunc(executor, func() (int, error) { return 2, nil }); !errors.As(err, &taskFull) {
+		t.Errorf("expect task full error, got %v", err)
+	}
+	if stats := executor.Stats(); stats.Queued != 1 || stats.Running != 1 || stats.HighWater != 1 || stats.Rejected != 1 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	close(block)
+	if v, err := queued.Get(); v != 1 || err != nil {
+		t.Errorf("expect (1, nil), got (%d, %v)", v, err)
+	}
+	executor.Shutdown()
+
+	executor, block = newExecutor(DiscardOldestPolicy())
+	oldest, _ := SubmitFunc(executor, func() (int, error) { return 1, nil })
+	newest, err := SubmitFunc(executor, func() (int, error) { return 2, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := oldest.Get(); !errors.As(err, &taskFull) {
+		t.Errorf("oldest task should be discarded, got %v", err)
+	}
+	close(block)
+	if v, err := newest.Get(); v != 2 || err != nil {
+		t.Errorf("expect (2, nil), got (%d, %v)", v, err)
+	}
+	executor.Shutdown()
+
+	executor, block = newExecutor(DiscardPolicy())
+	executor.Async(Nop())
+	if err := executor.Sync(Func(func() { t.Error("discarded task should not run") })); !errors.As(err, &taskFull) {
+		t.Errorf("expect task full error, got %v", err)
+	}
+	// 同步提交的 FutureTask 被丢弃时 Sync 和 FutureTask 都需要完成
+	future := NewFutureFunc(func() (int, error) { return 1, nil })
+	if err := executor.Sync(future); !errors.As(err, &taskFull) {
+		t.Errorf("expect task full error, got %v", err)
+	}
+	if _, err := future.WaitTimeout(time.Second); !errors.As(err, &taskFull) {
+		t.Errorf("discarded future task should be completed, got %v", err)
+	}
+	close(block)
+	executor.Shutdown()
+
+	executor, block = newExecutor(CallerRunsPolicy())
+	executor.Async(Nop())
+	ran := false
+	if err := executor.Async(Func(func() { ran = true })); err != nil || !ran {
+		t.Errorf("task should run in caller, err %v", err)
+	}
+	if stats := executor.Stats(); stats.CallerRuns != 1 || stats.Rejected != 0 {
+		t.Errorf("expect 1 caller run and 0 rejected, got %d and %d", stats.CallerRuns, stats.Rejected)
+	}
+	close(block)
+	executor.Shutdown()
+
+	executor, block = newExecutor(BlockPolicy(time.Millisecond * 20))
+	executor.Async(Nop())
+	begin := time.Now()
+	if err := executor.Async(Nop()); !errors.As(err, &taskFull) || time.Since(begin) < time.Millisecond*20 {
+		t.Errorf("expect task full error after blocking, got %v", err)
+	}
+	time.AfterFunc(time.Millisecond*10, func() { close(block) })
+	if err := executor.Async(Nop()); err != nil {
+		t.Errorf("task should be accepted after queue has space, got %v", err)
+	}
+	executor.Shutdown()
+}
+
+func TestTaskExecutorDrain(t *testing.T) {
+	run := func(policy DrainPolicy, tasks ...Task) *TaskExecutor {
+		executor := NewTaskExecutor(Unbounded, WithDrainPolicy(policy))
+		if err := executor.Start(); err != nil {
+			t.Fatal(err)
+		}
+		// 阻塞工作协程直到执行器关闭，使提交的任务都剩余在队列中
+		started := make(chan struct{})
+		executor.Async(Interrupted(func(interrupter chan struct{}) bool {
+			close(started)
+			<-interrupter
+			return true
+		}))
+		<-started
+		for _, task := range tasks {
+			executor.Async(task)
+		}
+		executor.Shutdown()
+		return executor
+	}
+
+	var ran atomic.Int64
+	count := Func(func() { ran.Add(1) })
+	run(DrainAll(), count, count)
+	if ran.Load() != 2 {
+		t.Errorf("all pending tasks should run, %d ran", ran.Load())
+	}
+
+	var dropped []Task
+	future := NewFutureFunc(func() (int, error) { return 1, nil })
+	executor := run(DiscardPending(func(task Task) { dropped = append(dropped, task) }), count, future)
+	if len(dropped) != 2 || executor.Stats().Dropped != 2 || ran.Load() != 2 {
+		t.Errorf("pending tasks should be dropped, dropped %d, ran %d", len(dropped), ran.Load()-2)
+	}
+	if _, err := future.Get(); err != TaskDroppedError {
+		t.Errorf("expect dropped error, got %v", err)
+	}
+
+	dropped = nil
+	interrupted := false
+	slow := Interrupted(func(interrupter chan struct{}) bool {
+		<-interrupter
+		interrupted = true
+		return true
+	})
+	begin := time.Now()
+	run(DrainTimeout(time.Millisecond*30, func(task Task) { dropped = append(dropped, task) }), slow, count)
+	if !interrupted || len(dropped) != 1 || ran.Load() != 2 {
+		t.Errorf("slow task should be interrupted and the rest dropped, interrupted %t, dropped %d", interrupted, len(dropped))
+	}
+	if elapsed := time.Since(begin); elapsed > time.Millisecond*200 {
+		t.Errorf("drain should stop at deadline, elapsed %s", elapsed)
+	}
+}
@@ -1,5 +1,11 @@
 package task
 
+import (
+	"context"
+	"gitee.com/sy_183/common/lifecycle"
+	"gitee.com/sy_183/common/utils"
+)
+
 type Task interface {
 	Do(interrupter chan struct{}) (interrupted bool)
 }
@@ -36,3 +42,111 @@ func (f uninterruptedTaskFunc) Do(interrupter chan struct{}) (interrupted bool)
 	f()
 	return false
 }
+
+type contextTaskFunc func(ctx context.Context)
+
+// Context 创建一个基于上下文的任务，执行器中断任务时上下文被取消。如果任务执行期间收到
+// 了中断信号，则任务被认为是中断的
+func Context(fn func(ctx context.Context)) Task {
+	if fn == nil {
+		return Nop()
+	}
+	return contextTaskFunc(fn)
+}
+
+func (f contextTaskFunc) Do(interrupter chan struct{}) (interrupted bool) {
+	if interrupter == nil {
+		f(context.Background())
+		return false
+	}
+	ctx, stop := lifecycle.InterrupterContext(context.Background(), interrupter)
+	f(ctx)
+	stop()
+	_, interrupted = utils.ChanTryPop(interrupter)
+	return
+}
+
+// KeyedTask 为带有键的任务，任务执行器保证相同键的任务按照提交的顺序依次执行，不同键的
+// 任务可以并行执行
+type KeyedTask interface {
+	Task
+
+	Key() any
+}
+
+// PriorityTask 为带有优先级的任务，任务执行器优先执行优先级高的任务，相同优先级的任务按照
+// 提交的顺序执行。没有指定优先级的任务优先级为0
+type PriorityTask interface {
+	Task
+
+	Priority() int
+}
+
+func taskKey(task Task) any {
+	if keyed, is := task.(KeyedTask); is {
+		return keyed.Key()
+	}
+	return nil
+}
+
+func taskPriority(task Task) int {
+	if prioritized, is := task.(PriorityTask); is {
+		return prioritized.Priority()
+	}
+	return 0
+}
+
+type keyedTask struct {
+	Task
+	key any
+}
+
+func (t keyedTask) Key() any {
+	return t.key
+}
+
+func (t keyedTask) Priority() int {
+	return taskPriority(t.Task)
+}
+
+func (t keyedTask) unwrap() Task {
+	return t.Task
+}
+
+// WithKey 为任务指定键，如果键为nil，则返回原任务
+func WithKey(key any, task Task) Task {
+	if key == nil {
+		return task
+	}
+	return keyedTask{Task: task, key: key}
+}
+
+type prioritizedTask struct {
+	Task
+	priority int
+}
+
+func (t prioritizedTask) Key() any {
+	return taskKey(t.Task)
+}
+
+func (t prioritizedTask) Priority() int {
+	return t.priority
+}
+
+func (t prioritizedTask) unwrap() Task {
+	return t.Task
+}
+
+// WithPriority 为任务指定优先级，数值越大优先级越高
+func WithPriority(priority int, task Task) Task {
+	return prioritizedTask{Task: task, priority: priority}
+}
+
+// inherit 使用原任务的键和优先级包装新的任务
+func inherit(origin Task, task Task) Task {
+	if priority := taskPriority(origin); priority != 0 {
+		task = WithPriority(priority, task)
+	}
+	return WithKey(taskKey(origin), task)
+}
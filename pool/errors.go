@@ -1,6 +1,9 @@
 package pool
 
-import "fmt"
+import (
+	"fmt"
+	"gitee.com/sy_183/common/errors"
+)
 
 type InvalidReferenceError struct {
 	CurRef  int64
@@ -32,3 +35,17 @@ func NewAllocError(target string) error {
 func (e AllocError) Error() string {
 	return fmt.Sprintf("申请%s失败", e.Target)
 }
+
+var RateLimitInterruptedError = errors.New("等待速率限制被中断")
+
+type RateLimitExceededError struct {
+	N int64
+}
+
+func NewRateLimitExceededError(n int64) error {
+	return RateLimitExceededError{N: n}
+}
+
+func (e RateLimitExceededError) Error() string {
+	return fmt.Sprintf("请求数量(%d)超过了速率限制器允许的最大值", e.N)
+}
@@ -0,0 +1,129 @@
+package pool
+
+import (
+	"sync"
+	"time"
+)
+
+type keyLimiterEntry struct {
+	limiter  RateLimiter
+	lastUsed time.Time
+}
+
+// HierarchicalLimiter 为分层速率限制器，请求需要同时满足全局的速率限制和请求所属的键的速
+// 率限制。每个键的速率限制器在第一次使用时创建，空闲时间超过 idleTimeout 后被移除
+type HierarchicalLimiter[K comparable] struct {
+	global      RateLimiter
+	newLimiter  func(key K) RateLimiter
+	idleTimeout time.Duration
+
+	limiters  map[K]*keyLimiterEntry
+	lastSweep time.Time
+	mu        sync.Mutex
+}
+
+// NewHierarchicalLimiter 创建分层速率限制器，global 为nil时不限制全局速率，newLimiter 用
+// 于创建每个键的速率限制器，idleTimeout 小于等于0时不移除空闲的键
+func NewHierarchicalLimiter[K comparable](global RateLimiter, newLimiter func(key K) RateLimiter, idleTimeout time.Duration) *HierarchicalLimiter[K] {
+	return &HierarchicalLimiter[K]{
+		global:      global,
+		newLimiter:  newLimiter,
+		idleTimeout: idleTimeout,
+		limiters:    make(map[K]*keyLimiterEntry),
+		lastSweep:   time.Now(),
+	}
+}
+
+// sweep 移除空闲时间超过 idleTimeout 的键，调用时必须持有锁
+func (h *HierarchicalLimiter[K]) sweep(now time.Time) {
+	if h.idleTimeout <= 0 || now.Sub(h.lastSweep) < h.idleTimeout {
+		return
+	}
+	for key, entry := range h.limiters {
+		if now.Sub(entry.lastUsed) >= h.idleTimeout {
+			delete(h.limiters, key)
+		}
+	}
+	h.lastSweep = now
+}
+
+func (h *HierarchicalLimiter[K]) keyLimiter(key K) RateLimiter {
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sweep(now)
+	entry := h.limiters[key]
+	if entry == nil {
+		entry = &keyLimiterEntry{limiter: h.newLimiter(key)}
+		h.limiters[key] = entry
+	}
+	entry.lastUsed = now
+	return entry.limiter
+}
+
+// Len 返回当前存在的键的数量
+func (h *HierarchicalLimiter[K]) Len() int {
+	now := time.Now()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.sweep(now)
+	return len(h.limiters)
+}
+
+func (h *HierarchicalLimiter[K]) ReserveN(key K, n int64) *Reservation {
+	keyReservation := h.keyLimiter(key).ReserveN(n)
+	if h.global == nil || !keyReservation.ok {
+		return keyReservation
+	}
+	globalReservation := h.global.ReserveN(n)
+	if !globalReservation.ok {
+		keyReservation.Cancel()
+		return globalReservation
+	}
+	at := keyReservation.at
+	if globalReservation.at.After(at) {
+		at = globalReservation.at
+	}
+	return &Reservation{ok: true, n: n, at: at, cancel: func() {
+		keyReservation.Cancel()
+		globalReservation.Cancel()
+	}}
+}
+
+func (h *HierarchicalLimiter[K]) AllowN(key K, n int64) bool {
+	r := h.ReserveN(key, n)
+	if !r.ok {
+		return false
+	}
+	if r.Delay() > 0 {
+		r.Cancel()
+		return false
+	}
+	return true
+}
+
+func (h *HierarchicalLimiter[K]) WaitN(key K, n int64, interrupter chan struct{}) error {
+	return waitReservation(h.ReserveN(key, n), interrupter)
+}
+
+type hierarchicalKeyLimiter[K comparable] struct {
+	h   *HierarchicalLimiter[K]
+	key K
+}
+
+func (l hierarchicalKeyLimiter[K]) AllowN(n int64) bool {
+	return l.h.AllowN(l.key, n)
+}
+
+func (l hierarchicalKeyLimiter[K]) ReserveN(n int64) *Reservation {
+	return l.h.ReserveN(l.key, n)
+}
+
+func (l hierarchicalKeyLimiter[K]) WaitN(n int64, interrupter chan struct{}) error {
+	return l.h.WaitN(l.key, n, interrupter)
+}
+
+// Limiter 返回指定键的 RateLimiter，对返回的速率限制器的请求同时受到全局和此键的速率限制
+func (h *HierarchicalLimiter[K]) Limiter(key K) RateLimiter {
+	return hierarchicalKeyLimiter[K]{h: h, key: key}
+}
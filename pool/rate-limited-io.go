@@ -0,0 +1,126 @@
+package pool
+
+import (
+	"gitee.com/sy_183/common/unit"
+	"io"
+)
+
+// ByteRate 为字节速率的配置，可以直接从配置文件中解析，例如 rate: 10MiB。零值的配置
+// 不限制速率
+type ByteRate struct {
+	// Rate 为每秒允许的字节数，为0时不限制速率
+	Rate unit.Size `yaml:"rate" json:"rate"`
+	// Burst 为允许突发的字节数，为0时与 Rate 相同
+	Burst unit.Size `yaml:"burst" json:"burst"`
+}
+
+func (r ByteRate) burst() int64 {
+	if r.Burst == 0 {
+		return r.Rate.Int64()
+	}
+	return r.Burst.Int64()
+}
+
+// Unlimited 判断配置是否不限制速率
+func (r ByteRate) Unlimited() bool {
+	return r.Rate == 0
+}
+
+// NewLimiter 使用配置创建令牌桶速率限制器，不限制速率时返回 nil 接口，可以直接传给
+// NewRateLimitedReader 和 NewRateLimitedWriter
+func (r ByteRate) NewLimiter() RateLimiter {
+	if r.Unlimited() {
+		return nil
+	}
+	return NewTokenBucket(r.Rate.Float64(), r.burst())
+}
+
+// Reader 使用配置创建限制读取速率的 Reader，不限制速率时直接读取
+func (r ByteRate) Reader(reader io.Reader) *RateLimitedReader {
+	return NewRateLimitedReader(reader, r.NewLimiter(), unit.Size(r.burst()))
+}
+
+// Writer 使用配置创建限制写入速率的 Writer，不限制速率时直接写入
+func (r ByteRate) Writer(writer io.Writer) *RateLimitedWriter {
+	return NewRateLimitedWriter(writer, r.NewLimiter(), unit.Size(r.burst()))
+}
+
+// RateLimitedReader 为限制读取速率的 Reader，每次读取的字节数不超过 chunk，读取后等待
+// 速率限制器允许读取的字节数
+type RateLimitedReader struct {
+	reader      io.Reader
+	limiter     RateLimiter
+	chunk       int
+	interrupter chan struct{}
+}
+
+// NewRateLimitedReader 创建限制读取速率的 Reader，chunk 不能超过速率限制器允许突发的
+// 请求数量，为0时不限制每次读取的字节数。limiter 为 nil 时不限制读取速率
+func NewRateLimitedReader(reader io.Reader, limiter RateLimiter, chunk unit.Size) *RateLimitedReader {
+	return &RateLimitedReader{reader: reader, limiter: limiter, chunk: chunk.Int()}
+}
+
+// SetInterrupter 设置等待速率限制时使用的中断器，收到中断信号时读取返回
+// RateLimitInterruptedError
+func (r *RateLimitedReader) SetInterrupter(interrupter chan struct{}) *RateLimitedReader {
+	r.interrupter = interrupter
+	return r
+}
+
+func (r *RateLimitedReader) Read(p []byte) (n int, err error) {
+	if r.chunk > 0 && len(p) > r.chunk {
+		p = p[:r.chunk]
+	}
+	n, err = r.reader.Read(p)
+	if n > 0 && r.limiter != nil {
+		if e := r.limiter.WaitN(int64(n), r.interrupter); e != nil {
+			return n, e
+		}
+	}
+	return
+}
+
+// RateLimitedWriter 为限制写入速率的 Writer，写入的数据被分割为不超过 chunk 的块，每个
+// 块在速率限制器允许后写入
+type RateLimitedWriter struct {
+	writer      io.Writer
+	limiter     RateLimiter
+	chunk       int
+	interrupter chan struct{}
+}
+
+// NewRateLimitedWriter 创建限制写入速率的 Writer，chunk 不能超过速率限制器允许突发的
+// 请求数量，为0时不分割写入的数据。limiter 为 nil 时不限制写入速率
+func NewRateLimitedWriter(writer io.Writer, limiter RateLimiter, chunk unit.Size) *RateLimitedWriter {
+	return &RateLimitedWriter{writer: writer, limiter: limiter, chunk: chunk.Int()}
+}
+
+// SetInterrupter 设置等待速率限制时使用的中断器，收到中断信号时写入返回
+// RateLimitInterruptedError
+func (w *RateLimitedWriter) SetInterrupter(interrupter chan struct{}) *RateLimitedWriter {
+	w.interrupter = interrupter
+	return w
+}
+
+func (w *RateLimitedWriter) Write(p []byte) (n int, err error) {
+	if w.limiter == nil {
+		return w.writer.Write(p)
+	}
+	for len(p) > 0 {
+		block := p
+		if w.chunk > 0 && len(block) > w.chunk {
+			block = block[:w.chunk]
+		}
+		if err = w.limiter.WaitN(int64(len(block)), w.interrupter); err != nil {
+			return
+		}
+		var written int
+		written, err = w.writer.Write(block)
+		n += written
+		if err != nil {
+			return
+		}
+		p = p[len(block):]
+	}
+	return
+}
@@ -0,0 +1,209 @@
+package pool
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter 为速率限制器，n 为请求的数量，对于字节速率限制器 n 为字节数
+type RateLimiter interface {
+	// AllowN 判断当前是否允许 n 个请求，允许时立即消耗
+	AllowN(n int64) bool
+
+	// ReserveN 预约 n 个请求，返回的预约记录了执行请求前需要等待的时间，如果请求永远
+	// 无法被满足，预约的 OK 返回false
+	ReserveN(n int64) *Reservation
+
+	// WaitN 等待直到允许 n 个请求，等待期间收到中断信号时取消预约并返回
+	// RateLimitInterruptedError
+	WaitN(n int64, interrupter chan struct{}) error
+}
+
+// Allow 判断当前是否允许一个请求
+func Allow(limiter RateLimiter) bool {
+	return limiter.AllowN(1)
+}
+
+// Wait 等待直到允许一个请求
+func Wait(limiter RateLimiter, interrupter chan struct{}) error {
+	return limiter.WaitN(1, interrupter)
+}
+
+// Reservation 为速率限制器的预约，预约的请求在 Delay 返回的时间之后才可以执行
+type Reservation struct {
+	ok     bool
+	n      int64
+	at     time.Time
+	cancel func()
+	once   sync.Once
+}
+
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay 返回执行预约的请求之前需要等待的时间
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return math.MaxInt64
+	}
+	if d := time.Until(r.at); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// Cancel 取消预约并将预约的请求归还给速率限制器，只能在执行预约的请求之前调用，多次调用
+// 只有第一次有效
+func (r *Reservation) Cancel() {
+	if !r.ok || r.cancel == nil {
+		return
+	}
+	r.once.Do(r.cancel)
+}
+
+// waitReservation 等待预约的时间到达，等待期间收到中断信号时取消预约
+func waitReservation(r *Reservation, interrupter chan struct{}) error {
+	if !r.ok {
+		return NewRateLimitExceededError(r.n)
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-interrupter:
+		r.Cancel()
+		return RateLimitInterruptedError
+	}
+}
+
+// TokenBucket 为令牌桶速率限制器，令牌以固定的速率加入桶中，桶中最多存放 burst 个令牌，
+// 每个请求消耗一个令牌，允许不超过 burst 的突发请求
+type TokenBucket struct {
+	rate   float64
+	burst  int64
+	tokens float64
+	last   time.Time
+	mu     sync.Mutex
+}
+
+// NewTokenBucket 创建令牌桶速率限制器，rate 为每秒加入的令牌数量，初始时桶是满的
+func NewTokenBucket(rate float64, burst int64) *TokenBucket {
+	return &TokenBucket{rate: rate, burst: burst, tokens: float64(burst), last: time.Now()}
+}
+
+// advance 根据经过的时间向桶中加入令牌，调用时必须持有锁
+func (b *TokenBucket) advance(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens = math.Min(float64(b.burst), b.tokens+elapsed.Seconds()*b.rate)
+		b.last = now
+	}
+}
+
+func (b *TokenBucket) reserve(n int64, maxWait time.Duration) *Reservation {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if n > b.burst || b.rate <= 0 {
+		return &Reservation{n: n}
+	}
+	b.advance(now)
+	var wait time.Duration
+	if tokens := b.tokens - float64(n); tokens < 0 {
+		wait = time.Duration(-tokens / b.rate * float64(time.Second))
+	}
+	if maxWait >= 0 && wait > maxWait {
+		return &Reservation{n: n}
+	}
+	b.tokens -= float64(n)
+	return &Reservation{ok: true, n: n, at: now.Add(wait), cancel: func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.advance(time.Now())
+		b.tokens = math.Min(float64(b.burst), b.tokens+float64(n))
+	}}
+}
+
+func (b *TokenBucket) AllowN(n int64) bool {
+	return b.reserve(n, 0).ok
+}
+
+func (b *TokenBucket) ReserveN(n int64) *Reservation {
+	return b.reserve(n, -1)
+}
+
+func (b *TokenBucket) WaitN(n int64, interrupter chan struct{}) error {
+	return waitReservation(b.reserve(n, -1), interrupter)
+}
+
+// SetRate 修改令牌加入桶中的速率和桶的容量
+func (b *TokenBucket) SetRate(rate float64, burst int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.advance(time.Now())
+	b.rate, b.burst = rate, burst
+	b.tokens = math.Min(float64(burst), b.tokens)
+}
+
+// LeakyBucket 为漏桶速率限制器，请求按照固定的间隔依次通过，不允许突发请求。桶中最多
+// 存放 capacity 个等待通过的请求，桶满时预约失败
+type LeakyBucket struct {
+	interval time.Duration
+	capacity int64
+	next     time.Time
+	mu       sync.Mutex
+}
+
+// NewLeakyBucket 创建漏桶速率限制器，rate 为每秒通过的请求数量
+func NewLeakyBucket(rate float64, capacity int64) *LeakyBucket {
+	var interval time.Duration
+	if rate > 0 {
+		interval = time.Duration(float64(time.Second) / rate)
+	}
+	return &LeakyBucket{interval: interval, capacity: capacity}
+}
+
+func (b *LeakyBucket) reserve(n int64, maxWait time.Duration) *Reservation {
+	now := time.Now()
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.interval <= 0 {
+		return &Reservation{n: n}
+	}
+	at := b.next
+	if at.Before(now) {
+		at = now
+	}
+	wait := at.Sub(now)
+	// 等待时间对应的请求数量即为桶中还未通过的请求数量，新的请求中第一个请求在桶为空时
+	// 可以直接通过，不占用桶的容量
+	level := int64((wait + b.interval - 1) / b.interval)
+	if level+n > b.capacity+1 || (maxWait >= 0 && wait > maxWait) {
+		return &Reservation{n: n}
+	}
+	cost := b.interval * time.Duration(n)
+	b.next = at.Add(cost)
+	return &Reservation{ok: true, n: n, at: at, cancel: func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		b.next = b.next.Add(-cost)
+	}}
+}
+
+func (b *LeakyBucket) AllowN(n int64) bool {
+	return b.reserve(n, 0).ok
+}
+
+func (b *LeakyBucket) ReserveN(n int64) *Reservation {
+	return b.reserve(n, -1)
+}
+
+func (b *LeakyBucket) WaitN(n int64, interrupter chan struct{}) error {
+	return waitReservation(b.reserve(n, -1), interrupter)
+}
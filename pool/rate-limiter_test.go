@@ -0,0 +1,122 @@
+package pool
+
+import (
+	"bytes"
+	"gitee.com/sy_183/common/unit"
+	"gopkg.in/yaml.v3"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	bucket := NewTokenBucket(100, 5)
+	for i := 0; i < 5; i++ {
+		if !Allow(bucket) {
+			t.Fatalf("burst request %d should be allowed", i)
+		}
+	}
+	if Allow(bucket) {
+		t.Fatal("request exceeding burst should not be allowed")
+	}
+	r := bucket.ReserveN(2)
+	if delay := r.Delay(); !r.OK() || delay < time.Millisecond*15 || delay > time.Millisecond*20 {
+		t.Errorf("expect reservation delay about 20ms, got %s", delay)
+	}
+	r.Cancel()
+	if bucket.ReserveN(6).OK() {
+		t.Error("reservation exceeding burst should fail")
+	}
+
+	interrupter := make(chan struct{}, 1)
+	interrupter <- struct{}{}
+	if err := bucket.WaitN(5, interrupter); err != RateLimitInterruptedError {
+		t.Errorf("expect interrupted error, got %v", err)
+	}
+	begin := time.Now()
+	if err := bucket.WaitN(2, nil); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(begin); elapsed < time.Millisecond*10 {
+		t.Errorf("wait should block until tokens available, elapsed %s", elapsed)
+	}
+}
+
+func TestLeakyBucket(t *testing.T) {
+	bucket := NewLeakyBucket(100, 2)
+	if !Allow(bucket) || Allow(bucket) {
+		t.Fatal("leaky bucket should not allow burst requests")
+	}
+	r1, r2, r3 := bucket.ReserveN(1), bucket.ReserveN(1), bucket.ReserveN(1)
+	if !r1.OK() || !r2.OK() || r3.OK() {
+		t.Fatalf("leaky bucket should queue at most 2 requests, got %t %t %t", r1.OK(), r2.OK(), r3.OK())
+	}
+	if d1, d2 := r1.Delay(), r2.Delay(); d2-d1 < time.Millisecond*9 {
+		t.Errorf("queued requests should leak at fixed interval, got %s and %s", d1, d2)
+	}
+}
+
+func TestHierarchicalLimiter(t *testing.T) {
+	limiter := NewHierarchicalLimiter[string](NewTokenBucket(1, 3), func(key string) RateLimiter {
+		return NewTokenBucket(1, 2)
+	}, time.Millisecond*20)
+	if !limiter.AllowN("a", 2) || limiter.AllowN("a", 1) {
+		t.Fatal("per key limit should be applied")
+	}
+	if !limiter.AllowN("b", 1) || limiter.AllowN("b", 1) {
+		t.Fatal("global limit should be applied")
+	}
+	if limiter.Len() != 2 {
+		t.Fatalf("expect 2 keys, got %d", limiter.Len())
+	}
+	time.Sleep(time.Millisecond * 30)
+	if limiter.Len() != 0 {
+		t.Errorf("idle keys should be evicted, %d keys remain", limiter.Len())
+	}
+}
+
+func TestRateLimitedIO(t *testing.T) {
+	var rate ByteRate
+	if err := yaml.Unmarshal([]byte("rate: 10KiB\nburst: 1KiB\n"), &rate); err != nil {
+		t.Fatal(err)
+	}
+	if rate.Rate != 10*unit.KiBiByte || rate.Burst != unit.KiBiByte {
+		t.Fatalf("unexpected byte rate %+v", rate)
+	}
+
+	data := bytes.Repeat([]byte{'x'}, 3*unit.KiBiByte)
+	begin := time.Now()
+	read, err := io.ReadAll(rate.Reader(bytes.NewReader(data)))
+	if err != nil || len(read) != len(data) {
+		t.Fatalf("read %d bytes, err %v", len(read), err)
+	}
+	if elapsed := time.Since(begin); elapsed < time.Millisecond*150 {
+		t.Errorf("read 3KiB at 10KiB/s with 1KiB burst should take about 200ms, elapsed %s", elapsed)
+	}
+
+	buf := bytes.Buffer{}
+	begin = time.Now()
+	if n, err := rate.Writer(&buf).Write(data); err != nil || n != len(data) {
+		t.Fatalf("wrote %d bytes, err %v", n, err)
+	}
+	if elapsed := time.Since(begin); elapsed < time.Millisecond*150 {
+		t.Errorf("write 3KiB at 10KiB/s with 1KiB burst should take about 200ms, elapsed %s", elapsed)
+	}
+
+	// 零值的配置不限制速率
+	var unlimited ByteRate
+	if !unlimited.Unlimited() || unlimited.NewLimiter() != nil {
+		t.Error("zero byte rate should be unlimited")
+	}
+	if read, err := io.ReadAll(unlimited.Reader(bytes.NewReader(data))); err != nil || len(read) != len(data) {
+		t.Fatalf("unlimited read %d bytes, err %v", len(read), err)
+	}
+	buf.Reset()
+	if n, err := unlimited.Writer(&buf).Write(data); err != nil || n != len(data) || buf.Len() != len(data) {
+		t.Fatalf("unlimited wrote %d bytes, err %v", n, err)
+	}
+	buf.Reset()
+	if n, err := NewRateLimitedWriter(&buf, unlimited.NewLimiter(), 0).Write(data); err != nil || n != len(data) || buf.Len() != len(data) {
+		t.Fatalf("unlimited limiter wrote %d bytes, err %v", n, err)
+	}
+}
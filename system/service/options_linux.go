@@ -1,5 +1,15 @@
 package svc
 
+import (
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// DefaultReadinessInterval 为默认检查应用健康状态的间隔
+const DefaultReadinessInterval = time.Second
+
 func WithNotify(notify bool) Option {
 	return optionFunc(func(service Service) {
 		if systemdService, is := service.(*linuxSystemdService); is {
@@ -7,3 +17,31 @@ func WithNotify(notify bool) Option {
 		}
 	})
 }
+
+// ReadinessInterval Option specifies the interval of checking the health
+// of the application. The service notifies systemd READY=1 after the
+// application becomes healthy for the first time and updates STATUS when
+// the health changes, if interval is 0, the health is only checked once
+// after the application started
+func ReadinessInterval(interval time.Duration) Option {
+	return optionFunc(func(service Service) {
+		if systemdService, is := service.(*linuxSystemdService); is {
+			systemdService.readinessInterval = interval
+		}
+	})
+}
+
+// DumpOnSignal Option specifies the signals that trigger dumping the
+// lifecycle tree of the application to w in indented text format, if no
+// signal is specified, SIGUSR1 is used
+func DumpOnSignal(w io.Writer, sig ...os.Signal) Option {
+	if len(sig) == 0 {
+		sig = []os.Signal{syscall.SIGUSR1}
+	}
+	return optionFunc(func(service Service) {
+		if systemdService, is := service.(*linuxSystemdService); is {
+			systemdService.dumpWriter = w
+			systemdService.dumpSignals = sig
+		}
+	})
+}
@@ -2,8 +2,10 @@ package svc
 
 import (
 	"gitee.com/sy_183/common/lifecycle"
+	"io"
 	"os"
 	"os/signal"
+	"time"
 )
 
 var isLinuxSystemdService = false
@@ -18,20 +20,25 @@ type linuxSystemdService struct {
 	app      lifecycle.Lifecycle
 	exitCode int
 
-	systemdNotify bool
+	systemdNotify     bool
+	readinessInterval time.Duration
 
 	notifySignals  []os.Signal
 	signalCallback func(sig os.Signal) (exit bool)
 	exitCodeGetter func(err *Error) int
+
+	dumpWriter  io.Writer
+	dumpSignals []os.Signal
 }
 
 func New(name string, app lifecycle.Lifecycle, options ...Option) Service {
 	lss := &linuxSystemdService{
-		app:            app,
-		systemdNotify:  isLinuxSystemdService,
-		notifySignals:  DefaultNotifySignals,
-		signalCallback: DefaultSignalCallback,
-		exitCodeGetter: DefaultExitCodeGetter,
+		app:               app,
+		systemdNotify:     isLinuxSystemdService,
+		readinessInterval: DefaultReadinessInterval,
+		notifySignals:     DefaultNotifySignals,
+		signalCallback:    DefaultSignalCallback,
+		exitCodeGetter:    DefaultExitCodeGetter,
 	}
 	for _, option := range options {
 		option.apply(lss)
@@ -48,17 +55,59 @@ func (lss *linuxSystemdService) setExitCodeGetter(exitCodeGetter func(err *Error
 	lss.exitCodeGetter = exitCodeGetter
 }
 
+func (lss *linuxSystemdService) isDumpSignal(sig os.Signal) bool {
+	for _, dumpSignal := range lss.dumpSignals {
+		if sig == dumpSignal {
+			return true
+		}
+	}
+	return false
+}
+
+// healthNotifier 根据应用的健康状态通知 systemd，应用第一次健康时发送 READY=1，此后
+// 健康状态改变时通过 STATUS 更新服务的状态
+type healthNotifier struct {
+	app    lifecycle.Lifecycle
+	ready  bool
+	status string
+}
+
+func (n *healthNotifier) notify() {
+	health := lifecycle.GetHealth(n.app)
+	status := health.Status.String()
+	if health.Err != nil {
+		status += ": " + health.Err.Error()
+	}
+	if status != n.status {
+		n.status = status
+		SystemdNotify("STATUS=" + status)
+	}
+	if !n.ready && health.Healthy() {
+		n.ready = true
+		SystemdNotify("READY=1")
+	}
+}
+
 func (lss *linuxSystemdService) Run() int {
 	go lss.app.Run()
 
 	sigChan := make(chan os.Signal)
-	signal.Notify(sigChan, lss.notifySignals...)
+	// notifySignals 可能与 DefaultNotifySignals 或调用者的切片共享底层数组，需要复制后再追加
+	signals := make([]os.Signal, 0, len(lss.notifySignals)+len(lss.dumpSignals))
+	signals = append(append(signals, lss.notifySignals...), lss.dumpSignals...)
+	signal.Notify(sigChan, signals...)
 
 	startedWaiter := lss.app.StartedWaiter()
 	closedWaiter := make(lifecycle.ChanFuture[error], 1)
+	notifier := healthNotifier{app: lss.app}
+	var readinessC <-chan time.Time
 	for {
 		select {
 		case sig := <-sigChan:
+			if lss.isDumpSignal(sig) {
+				lifecycle.DumpText(lss.dumpWriter, lss.app)
+				continue
+			}
 			if lss.signalCallback(sig) {
 				if lss.systemdNotify {
 					SystemdNotify("STOPPING=1")
@@ -71,8 +120,17 @@ func (lss *linuxSystemdService) Run() int {
 			}
 			lss.app.AddClosedFuture(closedWaiter)
 			if lss.systemdNotify {
-				SystemdNotify("READY=1")
+				// 应用启动后不一定已经就绪，例如健康检查器需要在第一次检查通过后才是健康的，
+				// 所以定期检查应用的健康状态，应用健康后才通知 systemd 服务已就绪
+				notifier.notify()
+				if lss.readinessInterval > 0 {
+					ticker := time.NewTicker(lss.readinessInterval)
+					defer ticker.Stop()
+					readinessC = ticker.C
+				}
 			}
+		case <-readinessC:
+			notifier.notify()
 		case err := <-closedWaiter:
 			if err != nil {
 				return lss.exitCodeGetter(&Error{Type: ExitError, Err: err})